@@ -1,49 +1,92 @@
-// This package is used only for DEV purposes. It is NOT used for PROD.
+// The standalone server, which serves every endpoint of the route table
+// in pkg/routes. It is used for local development and for container
+// deployments.
 //
-// The application is deployed as multiple serverless functions.
+// The application is also deployed as multiple serverless functions.
 // The hosting provider looks for function that comply with the
 // following signature:
 // func(http.ResponseWriter, *http.Request)
 //
 // For further deployment details, refer to now.json.
-// The file contains the routing definitions, which should be an
-// exact match with the routing in this file.
+// The file is generated by cmd/routes from the same route table.
 package main
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"os"
-	"survey-api/pkg/auth/api/login"
-	"survey-api/pkg/auth/api/logout"
-	"survey-api/pkg/auth/api/refresh"
-	"survey-api/pkg/auth/api/register"
-	pollapi "survey-api/pkg/poll/api"
-	pollvote "survey-api/pkg/poll/api/vote"
+	"survey-api/pkg/config"
+	"survey-api/pkg/di"
+	"survey-api/pkg/endpoint"
+	"survey-api/pkg/logger"
+	"survey-api/pkg/routes"
+	"survey-api/pkg/server"
+	"time"
+)
+
+const (
+	storageTimeout = 15 * time.Second
 )
 
 func main() {
-	host := os.Getenv("HOST")
-	if len(host) == 0 {
-		host = "localhost"
+	// Fail before serving any request, with every invalid setting reported.
+	config, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	port := os.Getenv("PORT")
-	if len(port) == 0 {
-		port = "3000"
+	err = routes.CheckRateLimits(&config.RateLimit)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("Survey server is running on http://" + host + ":" + port))
-	})
-	http.HandleFunc("/register", register.Handler())
-	http.HandleFunc("/login", login.Handler())
-	http.HandleFunc("/logout", logout.Handler())
-	http.HandleFunc("/token/refresh", refresh.Handler())
-	http.HandleFunc("/poll", pollapi.Handler())
-	http.HandleFunc("/poll/vote", pollvote.Handler())
-
-	err := http.ListenAndServe(host+":"+port, nil)
+	container, err := di.ContainerWith(config)
 	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Refuse to start without the storage, or with pending migrations.
+	err = waitForStorage(container)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	rt := endpoint.New(container)
+	routes.Register(rt, container)
+
+	container.Logger.Info("Survey server is listening", logger.Fields{"addr": config.Server.Host + ":" + config.Server.Port})
+	err = server.Run(&config.Server, rt, func(ctx context.Context) error {
+		err := container.Tracing.Shutdown(ctx)
+		if err != nil {
+			container.Logger.Error("Failed to export the remaining spans", logger.Fields{"error": err})
+		}
+
+		if container.PostgresDB != nil {
+			return container.PostgresDB.Close()
+		}
+
+		if container.MongoClient != nil {
+			return container.MongoClient.Disconnect(ctx)
+		}
+
+		return nil
+	})
+	if err != nil && err != http.ErrServerClosed {
 		panic(err)
 	}
+
+	container.Logger.Info("Survey server stopped")
+}
+
+// Migrations are never applied implicitly, see cmd/migrate. The memory
+// storage has none.
+func waitForStorage(container *di.Dependencies) error {
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+	return container.StorageGate.Wait(ctx)
 }
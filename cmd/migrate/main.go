@@ -0,0 +1,180 @@
+// Applies and reverts the versioned migrations of pkg/migration for the
+// configured STORAGE, which own every table and index of the database.
+// Applied migrations are recorded in the migrations collection of MongoDB
+// or in the schema_migrations table of PostgreSQL. Run it once per
+// deployment:
+// go run ./cmd/migrate status
+// go run ./cmd/migrate up [-to version] [-dry-run]
+// go run ./cmd/migrate down [-to version] [-dry-run]
+// Without -to, up applies every pending migration and down reverts the
+// latest applied one.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"survey-api/pkg/config"
+	"survey-api/pkg/migration"
+	"survey-api/pkg/mongodb"
+	"survey-api/pkg/postgres"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	timeout = 10 * time.Minute
+)
+
+var (
+	errUsage    = errors.New("Usage: migrate status | up [-to version] [-dry-run] | down [-to version] [-dry-run]")
+	errNoSchema = errors.New("The memory storage has nothing to migrate")
+)
+
+func main() {
+	err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	target := flags.Int("to", -1, "the version to migrate to")
+	dryRun := flags.Bool("dry-run", false, "only print the migrations")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return errUsage
+	}
+
+	config, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	service, disconnect, err := connect(config)
+	if err != nil {
+		return err
+	}
+
+	defer disconnect()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	switch command {
+	case "status":
+		return status(ctx, service)
+	case "up":
+		if *target < 0 {
+			*target = 0
+		}
+
+		migrations, err := service.Up(ctx, *target, *dryRun)
+		report("Applied", migrations, *dryRun)
+		return err
+	case "down":
+		if *target < 0 {
+			*target, err = previousVersion(ctx, service)
+			if err != nil {
+				return err
+			}
+		}
+
+		migrations, err := service.Down(ctx, *target, *dryRun)
+		report("Reverted", migrations, *dryRun)
+		return err
+	default:
+		return errUsage
+	}
+}
+
+// Connects to the configured storage, which is disconnected by the
+// returned function.
+func connect(c *config.Config) (*migration.Service, func(), error) {
+	switch c.Storage {
+	case config.MongodbStorage:
+		client, err := mongodb.NewClient(c)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		disconnect := func() {
+			client.Disconnect(context.Background())
+		}
+		return migration.NewMongodb(mongodb.NewDatabase(client, c)), disconnect, nil
+	case config.PostgresStorage:
+		db, err := postgres.NewDB(c)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		disconnect := func() {
+			db.Close()
+		}
+		return migration.NewPostgres(db), disconnect, nil
+	default:
+		return nil, nil, errNoSchema
+	}
+}
+
+func status(ctx context.Context, service *migration.Service) error {
+	statuses, err := service.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintln(w, strconv.Itoa(status.Migration.Version)+"\t"+status.Migration.Name+"\t"+applied)
+	}
+
+	return w.Flush()
+}
+
+// The version before the latest applied migration, so that down reverts a
+// single migration by default.
+func previousVersion(ctx context.Context, service *migration.Service) (int, error) {
+	statuses, err := service.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	previous := 0
+	latest := 0
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			previous = latest
+			latest = status.Migration.Version
+		}
+	}
+
+	return previous, nil
+}
+
+func report(action string, migrations []*migration.Migration, dryRun bool) {
+	if dryRun {
+		action = "[dry run] " + action
+	}
+
+	if len(migrations) == 0 {
+		fmt.Println("Nothing to migrate")
+	}
+
+	for _, migration := range migrations {
+		fmt.Println(action + " " + strconv.Itoa(migration.Version) + " " + migration.Name)
+	}
+}
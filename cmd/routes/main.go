@@ -0,0 +1,49 @@
+// Generates now.json from the route table in pkg/routes.
+//
+// Run from the root of the repository:
+//
+//	go run ./cmd/routes          writes now.json
+//	go run ./cmd/routes -check   fails if now.json is out of date
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"survey-api/pkg/routes"
+)
+
+func main() {
+	file := flag.String("file", "now.json", "path of the generated file")
+	check := flag.Bool("check", false, "only check that the file is up to date")
+	flag.Parse()
+
+	result, err := routes.MarshalNow()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *check {
+		current, err := ioutil.ReadFile(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		if !bytes.Equal(current, result) {
+			fmt.Fprintln(os.Stderr, *file+" is out of date, run: go run ./cmd/routes")
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	err = ioutil.WriteFile(*file, result, 0644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -7,6 +7,14 @@ require (
 	github.com/go-ozzo/ozzo-validation/v4 v4.1.0
 	github.com/google/wire v0.4.0
 	github.com/gorilla/securecookie v1.1.1
+	github.com/lib/pq v1.10.9
+	github.com/prometheus/client_golang v1.7.1
 	go.mongodb.org/mongo-driver v1.3.1
-	golang.org/x/crypto v0.0.0-20190530122614-20be4c3c3ed5
+	go.opentelemetry.io/otel v0.20.0
+	go.opentelemetry.io/otel/exporters/otlp v0.20.0
+	go.opentelemetry.io/otel/exporters/stdout v0.20.0
+	go.opentelemetry.io/otel/sdk v0.20.0
+	go.opentelemetry.io/otel/trace v0.20.0
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	golang.org/x/text v0.3.2
 )
@@ -0,0 +1,177 @@
+package apperror
+
+import (
+	"errors"
+	"survey-api/pkg/storage"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	KindInternal Kind = iota
+	KindBadRequest
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindMethodNotAllowed
+	KindPayloadTooLarge
+	KindTooManyRequests
+	KindUnavailable
+)
+
+var (
+	ErrMalformedBody    = BadRequest("Malformed request body")
+	ErrResourceNotFound = NotFound("Resource not found")
+	ErrMethodNotAllowed = &Error{Kind: KindMethodNotAllowed, Message: "Method not allowed"}
+	ErrBodyTooLarge     = &Error{Kind: KindPayloadTooLarge, Message: "Request body is too large"}
+	ErrTooManyRequests  = &Error{Kind: KindTooManyRequests, Message: "Too many requests"}
+	ErrUnavailable      = Unavailable("Service is temporarily unavailable")
+)
+
+type Kind int
+
+// Error is a domain error, which carries everything needed to render it
+// for a client. Errors of any other type are treated as internal and their
+// details are never exposed.
+type Error struct {
+	Kind    Kind
+	Message string
+	// Per-field details, keyed by the JSON name of the field.
+	Fields map[string]string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithField returns a copy of the error with an additional field detail,
+// so that package level errors can be safely extended.
+func (e *Error) WithField(field string, message string) *Error {
+	fields := make(map[string]string, len(e.Fields)+1)
+	for key, value := range e.Fields {
+		fields[key] = value
+	}
+
+	fields[field] = message
+	return &Error{
+		Kind:    e.Kind,
+		Message: e.Message,
+		Fields:  fields,
+		Err:     e.Err,
+	}
+}
+
+func BadRequest(message string) *Error {
+	return &Error{Kind: KindBadRequest, Message: message}
+}
+
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+func Unavailable(message string) *Error {
+	return &Error{Kind: KindUnavailable, Message: message}
+}
+
+// Validation converts the errors returned by ozzo-validation, with one
+// field detail for every invalid field. Nested fields are joined by dots,
+// for example "options.0.content".
+func Validation(err error) *Error {
+	var internalError validation.InternalError
+	if errors.As(err, &internalError) {
+		return &Error{Kind: KindInternal, Message: "Validation failed", Err: err}
+	}
+
+	fields := make(map[string]string)
+	var validationErrors validation.Errors
+	if errors.As(err, &validationErrors) {
+		flattenValidationErrors("", validationErrors, fields)
+	}
+
+	return &Error{
+		Kind:    KindValidation,
+		Message: "Validation failed",
+		Fields:  fields,
+		Err:     err,
+	}
+}
+
+// InvalidField is a validation error of a single field, for the checks that
+// cannot be expressed with ozzo-validation rules.
+func InvalidField(field string, message string) *Error {
+	return &Error{
+		Kind:    KindValidation,
+		Message: "Validation failed",
+		Fields:  map[string]string{field: message},
+	}
+}
+
+// From returns the domain error wrapped by err, or an internal error.
+// Storage errors, which were not translated by a service, are mapped to
+// their closest domain error.
+func From(err error) *Error {
+	var appError *Error
+	if errors.As(err, &appError) {
+		return appError
+	}
+
+	if errors.Is(err, storage.ErrNotFound) {
+		return &Error{Kind: KindNotFound, Message: "Resource not found", Err: err}
+	}
+
+	if errors.Is(err, storage.ErrUnavailable) {
+		return &Error{Kind: KindUnavailable, Message: ErrUnavailable.Message, Err: err}
+	}
+
+	var duplicateError *storage.DuplicateError
+	if errors.As(err, &duplicateError) {
+		conflict := &Error{Kind: KindConflict, Message: "Resource already exists", Err: err}
+		if len(duplicateError.Field) != 0 {
+			conflict.Fields = map[string]string{duplicateError.Field: "is already taken"}
+		}
+
+		return conflict
+	}
+
+	return &Error{Kind: KindInternal, Message: "Internal server error", Err: err}
+}
+
+func flattenValidationErrors(prefix string, validationErrors validation.Errors, fields map[string]string) {
+	for key, err := range validationErrors {
+		field := key
+		if len(prefix) != 0 {
+			field = prefix + "." + key
+		}
+
+		nestedErrors, ok := err.(validation.Errors)
+		if ok {
+			flattenValidationErrors(field, nestedErrors, fields)
+			continue
+		}
+
+		fields[field] = err.Error()
+	}
+}
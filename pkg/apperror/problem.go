@@ -0,0 +1,76 @@
+package apperror
+
+import (
+	"encoding/json"
+	"net/http"
+	"survey-api/pkg/requestid"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemType        = "about:blank"
+)
+
+var (
+	statusCodes = map[Kind]int{
+		KindInternal:         http.StatusInternalServerError,
+		KindBadRequest:       http.StatusBadRequest,
+		KindValidation:       http.StatusUnprocessableEntity,
+		KindUnauthorized:     http.StatusUnauthorized,
+		KindForbidden:        http.StatusForbidden,
+		KindNotFound:         http.StatusNotFound,
+		KindConflict:         http.StatusConflict,
+		KindMethodNotAllowed: http.StatusMethodNotAllowed,
+		KindPayloadTooLarge:  http.StatusRequestEntityTooLarge,
+		KindTooManyRequests:  http.StatusTooManyRequests,
+		KindUnavailable:      http.StatusServiceUnavailable,
+	}
+)
+
+// Problem is the RFC 7807 representation of an error.
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Errors   map[string]string `json:"errors,omitempty"`
+	// The X-Request-ID of the request, to quote when reporting the error.
+	RequestId string `json:"request_id,omitempty"`
+}
+
+func StatusCode(err error) int {
+	return statusCodes[From(err).Kind]
+}
+
+func ToProblem(r *http.Request, err error) *Problem {
+	appError := From(err)
+	status := statusCodes[appError.Kind]
+	problem := &Problem{
+		Type:      problemType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  r.URL.Path,
+		Errors:    appError.Fields,
+		RequestId: requestid.FromContext(r.Context()),
+	}
+
+	if appError.Kind != KindInternal {
+		problem.Detail = appError.Message
+	}
+
+	return problem
+}
+
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	problem := ToProblem(r, err)
+	result, err := json.Marshal(problem)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	w.Write(result)
+}
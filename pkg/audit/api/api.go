@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"survey-api/pkg/apperror"
+	audithandler "survey-api/pkg/audit/handler"
+	"survey-api/pkg/audit/model"
+	authhandler "survey-api/pkg/auth/handler"
+	"survey-api/pkg/config"
+	"survey-api/pkg/di"
+	"survey-api/pkg/endpoint"
+	"survey-api/pkg/router"
+)
+
+var (
+	ErrNotAdmin = apperror.Forbidden("User cannot read the audit log")
+)
+
+var handler = endpoint.Serverless(Routes)
+
+type events struct {
+	Events []*model.ClientEvent `json:"events"`
+}
+
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler(w, r)
+}
+
+func Routes(rt *router.Router, container *di.Dependencies) {
+	Init(rt, container.Config, container.AuthHandler, container.AuditHandler)
+}
+
+func Init(rt *router.Router, config *config.Config, authHandler *authhandler.Service, auditHandler *audithandler.Service) {
+	// Filtered by actor_id, action, outcome and target, and by time with
+	// from and to as RFC 3339 times, newest first.
+	rt.Handle(http.MethodGet, "/audit", func(w http.ResponseWriter, r *http.Request) error {
+		if !config.Admin.IsAdmin(authhandler.UserId(r)) {
+			return ErrNotAdmin
+		}
+
+		query := r.URL.Query()
+		found, err := auditHandler.Find(r.Context(), &model.QueryParams{
+			ActorId: query.Get("actor_id"),
+			Action:  query.Get("action"),
+			Outcome: query.Get("outcome"),
+			Target:  query.Get("target"),
+			From:    query.Get("from"),
+			To:      query.Get("to"),
+			Limit:   query.Get("limit"),
+		})
+		if err != nil {
+			return err
+		}
+
+		result := &events{Events: make([]*model.ClientEvent, len(found))}
+		for i, event := range found {
+			result.Events[i] = event.ToClientEvent()
+		}
+
+		return router.WriteJSON(w, http.StatusOK, result)
+	}, authHandler.Authenticate)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"survey-api/pkg/apperror"
+	"survey-api/pkg/audit/model"
+	"survey-api/pkg/audit/repo"
+	"survey-api/pkg/logger"
+	"survey-api/pkg/requestid"
+	"survey-api/pkg/router"
+)
+
+const (
+	clientKey contextKey = iota
+)
+
+type contextKey int
+
+type client struct {
+	ip        string
+	userAgent string
+}
+
+type Service struct {
+	logger    *logger.Service
+	auditRepo repo.Repository
+}
+
+func New(logger *logger.Service, auditRepo repo.Repository) *Service {
+	return &Service{logger: logger, auditRepo: auditRepo}
+}
+
+// TrackClient is a middleware, which keeps the address and the user agent
+// of the client for the events of the request.
+func TrackClient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientKey, &client{
+			ip:        router.ClientIp(r),
+			userAgent: r.UserAgent(),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Record appends the event with the client and the id of the request. The
+// action already happened, so a failure to record it is logged instead of
+// failing the request.
+func (s *Service) Record(ctx context.Context, event *model.Event) {
+	client, ok := ctx.Value(clientKey).(*client)
+	if ok {
+		event.Ip = client.ip
+		event.UserAgent = client.userAgent
+	}
+
+	event.RequestId = requestid.FromContext(ctx)
+	_, err := s.auditRepo.InsertOne(ctx, event)
+	if err != nil {
+		s.logger.For(ctx).Error("Failed to record the audit event", logger.Fields{
+			"error":   err,
+			"action":  event.Action,
+			"outcome": event.Outcome,
+		})
+	}
+}
+
+func (s *Service) Find(ctx context.Context, params *model.QueryParams) ([]*model.Event, error) {
+	err := params.Validate()
+	if err != nil {
+		return nil, apperror.Validation(err)
+	}
+
+	return s.auditRepo.Find(ctx, params.ToQuery())
+}
@@ -0,0 +1,157 @@
+package model
+
+import (
+	"strconv"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ActionRegister   = "register"
+	ActionLogin      = "login"
+	ActionLogout     = "logout"
+	ActionRefresh    = "refresh"
+	ActionDeletePoll = "poll.delete"
+
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+var (
+	Actions  = []interface{}{ActionRegister, ActionLogin, ActionLogout, ActionRefresh, ActionDeletePoll}
+	Outcomes = []interface{}{OutcomeSuccess, OutcomeFailure}
+)
+
+// Event is a security-relevant action. Events are only ever appended.
+type Event struct {
+	Id   primitive.ObjectID `bson:"_id,omitempty"`
+	Time primitive.DateTime `bson:"time,omitempty"`
+	// Zero when the actor is unknown, like for a failed login.
+	ActorId primitive.ObjectID `bson:"actor_id,omitempty"`
+	Action  string             `bson:"action,omitempty"`
+	// What the action was about, like the poll id or the login identifier.
+	Target    string `bson:"target,omitempty"`
+	Ip        string `bson:"ip,omitempty"`
+	UserAgent string `bson:"user_agent,omitempty"`
+	Outcome   string `bson:"outcome,omitempty"`
+	// Why the action failed.
+	Reason    string `bson:"reason,omitempty"`
+	RequestId string `bson:"request_id,omitempty"`
+}
+
+type ClientEvent struct {
+	Id        string `json:"id"`
+	Time      string `json:"time"`
+	ActorId   string `json:"actor_id,omitempty"`
+	Action    string `json:"action"`
+	Target    string `json:"target,omitempty"`
+	Ip        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Outcome   string `json:"outcome"`
+	Reason    string `json:"reason,omitempty"`
+	RequestId string `json:"request_id,omitempty"`
+}
+
+// Query selects the events, which match every non-zero field, newest
+// first. From is inclusive and To exclusive.
+type Query struct {
+	ActorId primitive.ObjectID
+	Action  string
+	Outcome string
+	Target  string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+// QueryParams are the query parameters of the audit endpoint.
+type QueryParams struct {
+	ActorId string
+	Action  string
+	Outcome string
+	Target  string
+	From    string
+	To      string
+	Limit   string
+}
+
+func (e *Event) ToClientEvent() *ClientEvent {
+	event := &ClientEvent{
+		Id:        e.Id.Hex(),
+		Time:      e.Time.Time().UTC().Format(time.RFC3339Nano),
+		Action:    e.Action,
+		Target:    e.Target,
+		Ip:        e.Ip,
+		UserAgent: e.UserAgent,
+		Outcome:   e.Outcome,
+		Reason:    e.Reason,
+		RequestId: e.RequestId,
+	}
+	if !e.ActorId.IsZero() {
+		event.ActorId = e.ActorId.Hex()
+	}
+
+	return event
+}
+
+func (q QueryParams) Validate() error {
+	return validation.ValidateStruct(&q,
+		validation.Field(&q.ActorId, validation.By(isObjectId)),
+		validation.Field(&q.Action, validation.In(Actions...)),
+		validation.Field(&q.Outcome, validation.In(Outcomes...)),
+		validation.Field(&q.From, validation.Date(time.RFC3339)),
+		validation.Field(&q.To, validation.Date(time.RFC3339)),
+		validation.Field(&q.Limit, validation.By(isLimit)),
+	)
+}
+
+// ToQuery converts validated parameters.
+func (q *QueryParams) ToQuery() *Query {
+	query := &Query{
+		Action:  q.Action,
+		Outcome: q.Outcome,
+		Target:  q.Target,
+		Limit:   DefaultLimit,
+	}
+	query.ActorId, _ = primitive.ObjectIDFromHex(q.ActorId)
+	query.From, _ = time.Parse(time.RFC3339, q.From)
+	query.To, _ = time.Parse(time.RFC3339, q.To)
+	if len(q.Limit) != 0 {
+		query.Limit, _ = strconv.Atoi(q.Limit)
+	}
+
+	return query
+}
+
+func isObjectId(value interface{}) error {
+	id, _ := value.(string)
+	if len(id) == 0 {
+		return nil
+	}
+
+	_, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return validation.NewError("validation_is_object_id", "must be an id")
+	}
+
+	return nil
+}
+
+func isLimit(value interface{}) error {
+	limit, _ := value.(string)
+	if len(limit) == 0 {
+		return nil
+	}
+
+	number, err := strconv.Atoi(limit)
+	if err != nil || number < 1 || number > MaxLimit {
+		return validation.NewError("validation_limit", "must be a number from 1 to "+strconv.Itoa(MaxLimit))
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package repo_test
+
+import (
+	"survey-api/pkg/audit/repo"
+	"survey-api/pkg/audit/repo/repotest"
+	"survey-api/pkg/config"
+	"survey-api/pkg/mongodb/mongodbtest"
+	"survey-api/pkg/postgres/postgrestest"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.Repository {
+		return repo.NewMemory()
+	})
+}
+
+func TestMongodb(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.Repository {
+		return repo.New(mongodbtest.Database(t), config.Default())
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.Repository {
+		return repo.NewPostgres(postgrestest.DB(t), config.Default())
+	})
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"survey-api/pkg/audit/model"
+	"survey-api/pkg/metrics"
+	"survey-api/pkg/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/semconv"
+)
+
+// Instrumented measures the duration of every operation of a Repository
+// and traces it.
+type Instrumented struct {
+	next    Repository
+	observe func(method string, start time.Time, err error)
+	tracing *tracing.Service
+	storage string
+}
+
+func NewInstrumented(next Repository, metrics *metrics.Service, tracingService *tracing.Service, storage string) *Instrumented {
+	return &Instrumented{
+		next:    next,
+		observe: metrics.StorageObserver(storage, "audit"),
+		tracing: tracingService,
+		storage: storage,
+	}
+}
+
+// The returned function ends the operation.
+func (i *Instrumented) begin(ctx context.Context, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := i.tracing.Start(ctx, "audit."+method,
+		semconv.DBSystemKey.String(i.storage),
+		semconv.DBOperationKey.String(method),
+	)
+	return ctx, func(err error) {
+		tracing.End(span, err)
+		i.observe(method, start, err)
+	}
+}
+
+func (i *Instrumented) InsertOne(ctx context.Context, event *model.Event) (*model.Event, error) {
+	ctx, end := i.begin(ctx, "InsertOne")
+	event, err := i.next.InsertOne(ctx, event)
+	end(err)
+	return event, err
+}
+
+func (i *Instrumented) Find(ctx context.Context, query *model.Query) ([]*model.Event, error) {
+	ctx, end := i.begin(ctx, "Find")
+	events, err := i.next.Find(ctx, query)
+	end(err)
+	return events, err
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"survey-api/pkg/audit/model"
+	"sync"
+)
+
+// Memory is an in-memory implementation of Repository for tests and local
+// demos. It is safe for concurrent use.
+type Memory struct {
+	mutex  sync.RWMutex
+	events []model.Event
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) InsertOne(ctx context.Context, event *model.Event) (*model.Event, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	prepare(event)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.events = append(m.events, *event)
+	return event, nil
+}
+
+func (m *Memory) Find(ctx context.Context, query *model.Query) ([]*model.Event, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.RLock()
+	events := []*model.Event{}
+	for _, event := range m.events {
+		if matchesEvent(query, &event) {
+			event := event
+			events = append(events, &event)
+		}
+	}
+	m.mutex.RUnlock()
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Time != events[j].Time {
+			return events[i].Time > events[j].Time
+		}
+
+		return events[i].Id.Hex() > events[j].Id.Hex()
+	})
+	if len(events) > limit(query) {
+		events = events[:limit(query)]
+	}
+
+	return events, nil
+}
+
+func matchesEvent(query *model.Query, event *model.Event) bool {
+	eventTime := event.Time.Time()
+	return (query.ActorId.IsZero() || query.ActorId == event.ActorId) &&
+		(len(query.Action) == 0 || query.Action == event.Action) &&
+		(len(query.Outcome) == 0 || query.Outcome == event.Outcome) &&
+		(len(query.Target) == 0 || query.Target == event.Target) &&
+		(query.From.IsZero() || !eventTime.Before(query.From)) &&
+		(query.To.IsZero() || eventTime.Before(query.To))
+}
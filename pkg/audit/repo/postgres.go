@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"survey-api/pkg/audit/model"
+	"survey-api/pkg/config"
+	"survey-api/pkg/postgres"
+	"survey-api/pkg/storage"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	eventColumns = "id, time, actor_id, action, target, ip, user_agent, outcome, reason, request_id"
+)
+
+// Postgres is the PostgreSQL implementation of Repository.
+type Postgres struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewPostgres(db *sql.DB, config *config.Config) *Postgres {
+	return &Postgres{db: db, timeout: config.Postgres.OperationTimeout}
+}
+
+func (p *Postgres) InsertOne(ctx context.Context, event *model.Event) (*model.Event, error) {
+	prepare(event)
+	var actorId interface{}
+	if !event.ActorId.IsZero() {
+		actorId = event.ActorId.Hex()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	_, err := p.db.ExecContext(ctx, "INSERT INTO audit_events ("+eventColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		event.Id.Hex(), event.Time.Time().UTC(), actorId, event.Action, event.Target,
+		event.Ip, event.UserAgent, event.Outcome, event.Reason, event.RequestId)
+	if err != nil {
+		return nil, storage.FromPostgres(err, nil)
+	}
+
+	return event, nil
+}
+
+func (p *Postgres) Find(ctx context.Context, query *model.Query) ([]*model.Event, error) {
+	var params postgres.Params
+	var conditions []string
+	if !query.ActorId.IsZero() {
+		conditions = append(conditions, "actor_id = "+params.Add(query.ActorId.Hex()))
+	}
+
+	if len(query.Action) != 0 {
+		conditions = append(conditions, "action = "+params.Add(query.Action))
+	}
+
+	if len(query.Outcome) != 0 {
+		conditions = append(conditions, "outcome = "+params.Add(query.Outcome))
+	}
+
+	if len(query.Target) != 0 {
+		conditions = append(conditions, "target = "+params.Add(query.Target))
+	}
+
+	if !query.From.IsZero() {
+		conditions = append(conditions, "time >= "+params.Add(query.From.UTC()))
+	}
+
+	if !query.To.IsZero() {
+		conditions = append(conditions, "time < "+params.Add(query.To.UTC()))
+	}
+
+	statement := "SELECT " + eventColumns + " FROM audit_events" + postgres.Where(conditions) +
+		" ORDER BY time DESC, id DESC LIMIT " + strconv.Itoa(limit(query))
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	rows, err := p.db.QueryContext(ctx, statement, params.Args()...)
+	if err != nil {
+		return nil, storage.FromPostgres(err, nil)
+	}
+
+	defer rows.Close()
+	events := []*model.Event{}
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, storage.FromPostgres(err, nil)
+	}
+
+	return events, nil
+}
+
+func scanEvent(rows *sql.Rows) (*model.Event, error) {
+	var event model.Event
+	var id string
+	var actorId sql.NullString
+	var eventTime time.Time
+	err := rows.Scan(&id, &eventTime, &actorId, &event.Action, &event.Target,
+		&event.Ip, &event.UserAgent, &event.Outcome, &event.Reason, &event.RequestId)
+	if err != nil {
+		return nil, storage.FromPostgres(err, nil)
+	}
+
+	event.Time = primitive.NewDateTimeFromTime(eventTime)
+	event.Id, err = postgres.ObjectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if actorId.Valid {
+		event.ActorId, err = postgres.ObjectID(actorId.String)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &event, nil
+}
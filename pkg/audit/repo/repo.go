@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"context"
+	"survey-api/pkg/audit/model"
+	"survey-api/pkg/config"
+	"survey-api/pkg/storage"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Repository stores the audit events. It can only append and query them.
+type Repository interface {
+	// InsertOne sets the id of the event, and its time unless it is set.
+	InsertOne(ctx context.Context, event *model.Event) (*model.Event, error)
+	Find(ctx context.Context, query *model.Query) ([]*model.Event, error)
+}
+
+// Service is the MongoDB implementation of Repository.
+type Service struct {
+	db      *mongo.Database
+	timeout time.Duration
+}
+
+func New(db *mongo.Database, config *config.Config) *Service {
+	return &Service{db: db, timeout: config.Mongodb.OperationTimeout}
+}
+
+func (s *Service) InsertOne(ctx context.Context, event *model.Event) (*model.Event, error) {
+	prepare(event)
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	_, err := s.auditCollection().InsertOne(ctx, event)
+	defer cancel()
+	if err != nil {
+		return nil, storage.FromMongo(err, nil)
+	}
+
+	return event, nil
+}
+
+func (s *Service) Find(ctx context.Context, query *model.Query) ([]*model.Event, error) {
+	filter := bson.M{}
+	if !query.ActorId.IsZero() {
+		filter["actor_id"] = query.ActorId
+	}
+
+	if len(query.Action) != 0 {
+		filter["action"] = query.Action
+	}
+
+	if len(query.Outcome) != 0 {
+		filter["outcome"] = query.Outcome
+	}
+
+	if len(query.Target) != 0 {
+		filter["target"] = query.Target
+	}
+
+	timeRange := bson.M{}
+	if !query.From.IsZero() {
+		timeRange["$gte"] = primitive.NewDateTimeFromTime(query.From)
+	}
+
+	if !query.To.IsZero() {
+		timeRange["$lt"] = primitive.NewDateTimeFromTime(query.To)
+	}
+
+	if len(timeRange) != 0 {
+		filter["time"] = timeRange
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "time", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit(query)))
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	cursor, err := s.auditCollection().Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, storage.FromMongo(err, nil)
+	}
+
+	defer cursor.Close(ctx)
+	events := []*model.Event{}
+	for cursor.Next(ctx) {
+		var event model.Event
+		err = cursor.Decode(&event)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, &event)
+	}
+
+	err = cursor.Err()
+	if err != nil {
+		return nil, storage.FromMongo(err, nil)
+	}
+
+	return events, nil
+}
+
+func (s *Service) auditCollection() *mongo.Collection {
+	return s.db.Collection("audit")
+}
+
+func prepare(event *model.Event) {
+	event.Id = primitive.NewObjectID()
+	if event.Time == 0 {
+		event.Time = primitive.NewDateTimeFromTime(time.Now().UTC())
+	}
+}
+
+func limit(query *model.Query) int {
+	if query.Limit <= 0 || query.Limit > model.MaxLimit {
+		return model.DefaultLimit
+	}
+
+	return query.Limit
+}
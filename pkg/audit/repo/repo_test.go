@@ -0,0 +1,24 @@
+package repo
+
+import (
+	"context"
+	"survey-api/pkg/audit/model"
+	"survey-api/pkg/mongodb/mongodbtest"
+	"testing"
+	"time"
+)
+
+func TestOperationsFollowContext(t *testing.T) {
+	timeout := 50 * time.Millisecond
+	s := &Service{db: mongodbtest.Unreachable(t), timeout: timeout}
+	mongodbtest.TestCancellation(t, timeout, map[string]mongodbtest.Operation{
+		"InsertOne": func(ctx context.Context) error {
+			_, err := s.InsertOne(ctx, &model.Event{Action: model.ActionLogin, Outcome: model.OutcomeSuccess})
+			return err
+		},
+		"Find": func(ctx context.Context) error {
+			_, err := s.Find(ctx, &model.Query{Action: model.ActionLogin})
+			return err
+		},
+	})
+}
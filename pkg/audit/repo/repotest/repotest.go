@@ -0,0 +1,160 @@
+// Package repotest is the contract every implementation of the audit
+// repository has to satisfy.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"survey-api/pkg/audit/model"
+	"survey-api/pkg/audit/repo"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	start = time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// Run runs the contract against a new, empty repository for every test.
+func Run(t *testing.T, newRepository func(t *testing.T) repo.Repository) {
+	tests := map[string]func(t *testing.T, r repo.Repository){
+		"InsertAndFind":    testInsertAndFind,
+		"Filters":          testFilters,
+		"TimeRange":        testTimeRange,
+		"NewestFirst":      testNewestFirst,
+		"Limit":            testLimit,
+		"Empty":            testEmpty,
+		"CancelledContext": testCancelledContext,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepository(t))
+		})
+	}
+}
+
+func insert(t *testing.T, r repo.Repository, event *model.Event) *model.Event {
+	t.Helper()
+	event, err := r.InsertOne(context.Background(), event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return event
+}
+
+func find(t *testing.T, r repo.Repository, query *model.Query) []*model.Event {
+	t.Helper()
+	events, err := r.Find(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return events
+}
+
+// at returns the time of the nth event, a minute apart.
+func at(n int) primitive.DateTime {
+	return primitive.NewDateTimeFromTime(start.Add(time.Duration(n) * time.Minute))
+}
+
+func testInsertAndFind(t *testing.T, r repo.Repository) {
+	event := insert(t, r, &model.Event{
+		ActorId:   primitive.NewObjectID(),
+		Action:    model.ActionLogin,
+		Target:    "alice",
+		Ip:        "192.0.2.1",
+		UserAgent: "test",
+		Outcome:   model.OutcomeSuccess,
+		RequestId: "request",
+	})
+	if event.Id.IsZero() || event.Time == 0 {
+		t.Fatalf("expected an id and a time, got %+v", event)
+	}
+
+	events := find(t, r, &model.Query{})
+	if len(events) != 1 || *events[0] != *event {
+		t.Fatalf("expected %+v, got %+v", event, events)
+	}
+}
+
+func testFilters(t *testing.T, r repo.Repository) {
+	actorId := primitive.NewObjectID()
+	insert(t, r, &model.Event{Time: at(0), ActorId: actorId, Action: model.ActionLogin, Outcome: model.OutcomeSuccess})
+	insert(t, r, &model.Event{Time: at(1), ActorId: actorId, Action: model.ActionDeletePoll, Target: "poll", Outcome: model.OutcomeFailure, Reason: "forbidden"})
+	insert(t, r, &model.Event{Time: at(2), Action: model.ActionLogin, Target: "bob", Outcome: model.OutcomeFailure})
+
+	tests := []struct {
+		name     string
+		query    *model.Query
+		expected int
+	}{
+		{"actor", &model.Query{ActorId: actorId}, 2},
+		{"action", &model.Query{Action: model.ActionLogin}, 2},
+		{"outcome", &model.Query{Outcome: model.OutcomeFailure}, 2},
+		{"target", &model.Query{Target: "poll"}, 1},
+		{"every field", &model.Query{ActorId: actorId, Action: model.ActionLogin, Outcome: model.OutcomeSuccess}, 1},
+		{"no match", &model.Query{ActorId: primitive.NewObjectID()}, 0},
+	}
+	for _, test := range tests {
+		events := find(t, r, test.query)
+		if len(events) != test.expected {
+			t.Errorf("%s: expected %d events, got %d", test.name, test.expected, len(events))
+		}
+	}
+}
+
+func testTimeRange(t *testing.T, r repo.Repository) {
+	for n := 0; n < 5; n++ {
+		insert(t, r, &model.Event{Time: at(n), Action: model.ActionLogout, Outcome: model.OutcomeSuccess})
+	}
+
+	// From is inclusive and To exclusive.
+	events := find(t, r, &model.Query{From: at(1).Time(), To: at(3).Time()})
+	if len(events) != 2 || events[0].Time != at(2) || events[1].Time != at(1) {
+		t.Fatalf("expected the events at 2 and 1, got %+v", events)
+	}
+}
+
+func testNewestFirst(t *testing.T, r repo.Repository) {
+	insert(t, r, &model.Event{Time: at(1), Action: model.ActionLogin, Outcome: model.OutcomeSuccess})
+	insert(t, r, &model.Event{Time: at(3), Action: model.ActionLogin, Outcome: model.OutcomeSuccess})
+	insert(t, r, &model.Event{Time: at(2), Action: model.ActionLogin, Outcome: model.OutcomeSuccess})
+
+	events := find(t, r, &model.Query{})
+	for i, n := range []int{3, 2, 1} {
+		if events[i].Time != at(n) {
+			t.Fatalf("expected the newest events first, got %+v", events)
+		}
+	}
+}
+
+func testLimit(t *testing.T, r repo.Repository) {
+	for n := 0; n < 5; n++ {
+		insert(t, r, &model.Event{Time: at(n), Action: model.ActionRefresh, Outcome: model.OutcomeSuccess})
+	}
+
+	events := find(t, r, &model.Query{Limit: 2})
+	if len(events) != 2 || events[0].Time != at(4) {
+		t.Fatalf("expected the 2 newest events, got %+v", events)
+	}
+}
+
+func testEmpty(t *testing.T, r repo.Repository) {
+	events := find(t, r, &model.Query{})
+	if events == nil || len(events) != 0 {
+		t.Fatalf("expected an empty slice, got %#v", events)
+	}
+}
+
+func testCancelledContext(t *testing.T, r repo.Repository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := r.InsertOne(ctx, &model.Event{Action: model.ActionLogin, Outcome: model.OutcomeSuccess})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
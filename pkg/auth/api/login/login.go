@@ -1,70 +1,48 @@
 package login
 
 import (
-	"encoding/json"
 	"net/http"
 	authhandler "survey-api/pkg/auth/handler"
 	authmodel "survey-api/pkg/auth/model"
 	"survey-api/pkg/di"
-	"survey-api/pkg/logger"
+	"survey-api/pkg/endpoint"
+	"survey-api/pkg/router"
 	"survey-api/pkg/user/model"
 )
 
-var handler func(http.ResponseWriter, *http.Request)
+var handler = endpoint.Serverless(Routes)
 
-func Handler() func(http.ResponseWriter, *http.Request) {
-	return handler
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler(w, r)
 }
 
-func Init(logger *logger.Service, authHandler *authhandler.Service) func(http.ResponseWriter, *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
+func Routes(rt *router.Router, container *di.Dependencies) {
+	Init(rt, container.AuthHandler)
+}
 
-		var loginUser *model.LoginUser
-		err := json.NewDecoder(r.Body).Decode(&loginUser)
+func Init(rt *router.Router, authHandler *authhandler.Service) {
+	rt.Handle(http.MethodPost, "/login", func(w http.ResponseWriter, r *http.Request) error {
+		loginUser := &model.LoginUser{}
+		err := router.DecodeJSON(r, loginUser)
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
+			return err
 		}
 
-		user, err := authHandler.VerifyUserCredentials(loginUser)
+		user, err := authHandler.VerifyUserCredentials(r.Context(), loginUser)
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return err
 		}
 
-		cookie, token, err := authHandler.GenerateAuth(user)
+		cookie, token, err := authHandler.GenerateAuth(r.Context(), user)
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return err
 		}
 
 		authUser := &authmodel.AuthUser{
 			Token: token,
 			User:  user.ToClientUser(),
 		}
-		result, err := json.Marshal(authUser)
-		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
 		http.SetCookie(w, cookie)
-		w.WriteHeader(http.StatusOK)
-		w.Write(result)
-	}
-}
-
-func init() {
-	handler = Init(
-		di.Container().Logger,
-		di.Container().AuthHandler,
-	)
+		return router.WriteJSON(w, http.StatusOK, authUser)
+	})
 }
@@ -2,73 +2,66 @@ package logout
 
 import (
 	"net/http"
+	audithandler "survey-api/pkg/audit/handler"
+	auditmodel "survey-api/pkg/audit/model"
 	"survey-api/pkg/auth/cookie"
 	authhandler "survey-api/pkg/auth/handler"
 	authmodel "survey-api/pkg/auth/model"
 	authrepo "survey-api/pkg/auth/repo"
 	"survey-api/pkg/auth/token"
 	"survey-api/pkg/di"
-	"survey-api/pkg/logger"
+	"survey-api/pkg/endpoint"
+	"survey-api/pkg/router"
 )
 
-var handler func(http.ResponseWriter, *http.Request)
+var handler = endpoint.Serverless(Routes)
 
-func Handler() func(http.ResponseWriter, *http.Request) {
-	return handler
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler(w, r)
+}
+
+func Routes(rt *router.Router, container *di.Dependencies) {
+	Init(
+		rt,
+		container.AuthHandler,
+		container.AuditHandler,
+		container.AuthRepo,
+		container.TokenService,
+		container.CookieService,
+	)
 }
 
 func Init(
-	logger *logger.Service,
+	rt *router.Router,
 	authHandler *authhandler.Service,
-	authRepo *authrepo.Service,
+	auditHandler *audithandler.Service,
+	authRepo authrepo.Repository,
 	tokenService *token.Service,
 	cookieService *cookie.Service,
-) func(http.ResponseWriter, *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-
+) {
+	rt.Handle(http.MethodPost, "/logout", func(w http.ResponseWriter, r *http.Request) error {
 		token, err := tokenService.ParseJwtToken(r)
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
+			return authhandler.ErrInvalidToken
 		}
 
-		_, err = tokenService.ValidateJwtToken(token)
+		session, err := authRepo.FindOne(r.Context(), &authmodel.Session{Token: token})
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
+			return err
 		}
 
-		session, err := authRepo.FindOne(&authmodel.Session{Token: token})
+		err = authRepo.DeleteOne(r.Context(), session)
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		err = authRepo.DeleteOne(session)
-		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return err
 		}
 
+		auditHandler.Record(r.Context(), &auditmodel.Event{
+			ActorId: session.UserId,
+			Action:  auditmodel.ActionLogout,
+			Outcome: auditmodel.OutcomeSuccess,
+		})
 		http.SetCookie(w, cookieService.GenerateExpiredCookie())
 		w.WriteHeader(http.StatusOK)
-	}
-}
-
-func init() {
-	handler = Init(
-		di.Container().Logger,
-		di.Container().AuthHandler,
-		di.Container().AuthRepo,
-		di.Container().TokenService,
-		di.Container().CookieService,
-	)
+		return nil
+	}, authHandler.Authenticate)
 }
@@ -1,105 +1,106 @@
 package refresh
 
 import (
-	"encoding/json"
 	"net/http"
+	audithandler "survey-api/pkg/audit/handler"
+	auditmodel "survey-api/pkg/audit/model"
 	"survey-api/pkg/auth/cookie"
 	authhandler "survey-api/pkg/auth/handler"
 	authmodel "survey-api/pkg/auth/model"
 	authrepo "survey-api/pkg/auth/repo"
 	"survey-api/pkg/auth/token"
 	"survey-api/pkg/di"
-	"survey-api/pkg/logger"
+	"survey-api/pkg/endpoint"
+	"survey-api/pkg/router"
+	"survey-api/pkg/storage"
 	userrepo "survey-api/pkg/user/repo"
 )
 
-var handler func(http.ResponseWriter, *http.Request)
+var handler = endpoint.Serverless(Routes)
 
-func Handler() func(http.ResponseWriter, *http.Request) {
-	return handler
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler(w, r)
+}
+
+func Routes(rt *router.Router, container *di.Dependencies) {
+	Init(
+		rt,
+		container.CookieService,
+		container.TokenService,
+		container.AuthRepo,
+		container.UserRepo,
+		container.AuthHandler,
+		container.AuditHandler,
+	)
 }
 
 func Init(
-	logger *logger.Service,
+	rt *router.Router,
 	cookieService *cookie.Service,
 	tokenService *token.Service,
-	authRepo *authrepo.Service,
-	userRepo *userrepo.Service,
+	authRepo authrepo.Repository,
+	userRepo userrepo.Repository,
 	authHandler *authhandler.Service,
-) func(http.ResponseWriter, *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusNotFound)
-			return
+	auditHandler *audithandler.Service,
+) {
+	rt.Handle(http.MethodPost, "/token/refresh", func(w http.ResponseWriter, r *http.Request) error {
+		recordFailure := func(reason string) {
+			auditHandler.Record(r.Context(), &auditmodel.Event{
+				Action:  auditmodel.ActionRefresh,
+				Outcome: auditmodel.OutcomeFailure,
+				Reason:  reason,
+			})
 		}
 
 		cookie, err := cookieService.ParseSessionCookie(r)
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
+			recordFailure("Missing session cookie")
+			return authhandler.ErrInvalidSession
 		}
 
 		sessionId, err := cookieService.ValidateSessionCookie(cookie)
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			recordFailure("Invalid session cookie")
+			return authhandler.ErrInvalidSession
+		}
+
+		session, err := authRepo.FindById(r.Context(), sessionId)
+		if err == storage.ErrNotFound {
+			recordFailure("Unknown or expired session")
 		}
 
-		session, err := authRepo.FindById(sessionId)
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return err
 		}
 
 		var newCookie *http.Cookie
 		token := session.Token
 		_, err = tokenService.ValidateJwtToken(token)
 		if err != nil {
-			newCookie, token, err = authHandler.RefreshAuth(session)
+			newCookie, token, err = authHandler.RefreshAuth(r.Context(), session)
 			if err != nil {
-				logger.LogErr(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
+				return err
 			}
 		}
 
-		user, err := userRepo.FindById(session.UserId.Hex())
+		user, err := userRepo.FindById(r.Context(), session.UserId.Hex())
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return err
 		}
 
 		authUser := &authmodel.AuthUser{
 			Token: token,
 			User:  user.ToClientUser(),
 		}
-		result, err := json.Marshal(authUser)
-		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
 		if newCookie != nil {
-			http.SetCookie(w, cookie)
+			http.SetCookie(w, newCookie)
 		}
 
-		w.WriteHeader(http.StatusOK)
-		w.Write(result)
-	}
-}
-
-func init() {
-	handler = Init(
-		di.Container().Logger,
-		di.Container().CookieService,
-		di.Container().TokenService,
-		di.Container().AuthRepo,
-		di.Container().UserRepo,
-		di.Container().AuthHandler,
-	)
+		auditHandler.Record(r.Context(), &auditmodel.Event{
+			ActorId: session.UserId,
+			Action:  auditmodel.ActionRefresh,
+			Outcome: auditmodel.OutcomeSuccess,
+		})
+		return router.WriteJSON(w, http.StatusOK, authUser)
+	})
 }
@@ -1,71 +1,38 @@
 package register
 
 import (
-	"encoding/json"
 	"net/http"
 	authhandler "survey-api/pkg/auth/handler"
-	authmodel "survey-api/pkg/auth/model"
 	"survey-api/pkg/di"
-	"survey-api/pkg/logger"
+	"survey-api/pkg/endpoint"
+	"survey-api/pkg/router"
 	usermodel "survey-api/pkg/user/model"
 )
 
-var handler func(http.ResponseWriter, *http.Request)
+var handler = endpoint.Serverless(Routes)
 
-func Handler() func(http.ResponseWriter, *http.Request) {
-	return handler
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler(w, r)
 }
 
-func Init(logger *logger.Service, authHandler *authhandler.Service) func(http.ResponseWriter, *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-
-		var registerUser *usermodel.RegisterUser
-		err := json.NewDecoder(r.Body).Decode(&registerUser)
-		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-
-		user := registerUser.ToUser()
-		user, err = authHandler.Register(registerUser)
-		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+func Routes(rt *router.Router, container *di.Dependencies) {
+	Init(rt, container.AuthHandler)
+}
 
-		cookie, token, err := authHandler.GenerateAuth(user)
+func Init(rt *router.Router, authHandler *authhandler.Service) {
+	rt.Handle(http.MethodPost, "/register", func(w http.ResponseWriter, r *http.Request) error {
+		registerUser := &usermodel.RegisterUser{}
+		err := router.DecodeJSON(r, registerUser)
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return err
 		}
 
-		authUser := &authmodel.AuthUser{
-			Token: token,
-			User:  user.ToClientUser(),
-		}
-		result, err := json.Marshal(authUser)
+		err = authHandler.Register(r.Context(), registerUser)
 		if err != nil {
-			logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return err
 		}
 
-		http.SetCookie(w, cookie)
-		w.WriteHeader(http.StatusOK)
-		w.Write(result)
-	}
-}
-
-func init() {
-	handler = Init(
-		di.Container().Logger,
-		di.Container().AuthHandler,
-	)
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	})
 }
@@ -1,12 +1,11 @@
 package cookie
 
 import (
-	"errors"
 	"net/http"
-	"os"
 	"time"
 
 	sessionmodel "survey-api/pkg/auth/model"
+	"survey-api/pkg/config"
 
 	"github.com/gorilla/securecookie"
 )
@@ -17,15 +16,27 @@ const (
 	cookiePath          = "/token/refresh"
 )
 
+var (
+	sameSitePolicies = map[string]http.SameSite{
+		"strict": http.SameSiteStrictMode,
+		"lax":    http.SameSiteLaxMode,
+		"none":   http.SameSiteNoneMode,
+	}
+)
+
 type Service struct {
+	config   *config.Config
+	sameSite http.SameSite
 }
 
 type cookieStore struct {
 	SessionId string `json:"session_id"`
 }
 
-func New() *Service {
-	return &Service{}
+// New creates the cookies of the COOKIE_SAME_SITE policy, which is
+// validated by the configuration.
+func New(config *config.Config) *Service {
+	return &Service{config: config, sameSite: sameSitePolicies[config.Cookie.SameSite]}
 }
 
 func (s *Service) ParseSessionCookie(r *http.Request) (*http.Cookie, error) {
@@ -33,12 +44,7 @@ func (s *Service) ParseSessionCookie(r *http.Request) (*http.Cookie, error) {
 }
 
 func (s *Service) GenerateSessionCookie(session *sessionmodel.Session) (*http.Cookie, error) {
-	sessionKey := os.Getenv("SESSION_KEY")
-	if len(sessionKey) == 0 {
-		return nil, errors.New("SESSION_KEY is not set")
-	}
-
-	secureCookie := securecookie.New([]byte(sessionKey), nil)
+	secureCookie := securecookie.New([]byte(s.config.Auth.SessionKey), nil)
 	cookieStore := &cookieStore{SessionId: session.Id.Hex()}
 	encodedValue, err := secureCookie.Encode(cookieName, cookieStore)
 	if err != nil {
@@ -51,12 +57,7 @@ func (s *Service) GenerateSessionCookie(session *sessionmodel.Session) (*http.Co
 }
 
 func (s *Service) ValidateSessionCookie(sessionCookie *http.Cookie) (string, error) {
-	sessionKey := os.Getenv("SESSION_KEY")
-	if len(sessionKey) == 0 {
-		return "", errors.New("SESSION_KEY is not set")
-	}
-
-	secureCookie := securecookie.New([]byte(sessionKey), nil)
+	secureCookie := securecookie.New([]byte(s.config.Auth.SessionKey), nil)
 	cookieStore := &cookieStore{}
 	err := secureCookie.Decode(cookieName, sessionCookie.Value, &cookieStore)
 	if err != nil {
@@ -74,10 +75,11 @@ func (s *Service) generateCookie(value string, maxAge int) *http.Cookie {
 	cookie := &http.Cookie{
 		Name:     cookieName,
 		Path:     cookiePath,
+		Domain:   s.config.Cookie.Domain,
 		MaxAge:   maxAge,
 		Secure:   true,
 		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
+		SameSite: s.sameSite,
 	}
 
 	if len(value) != 0 {
@@ -1,110 +1,280 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"survey-api/pkg/apperror"
+	audithandler "survey-api/pkg/audit/handler"
+	auditmodel "survey-api/pkg/audit/model"
 	"survey-api/pkg/auth/cookie"
 	authmodel "survey-api/pkg/auth/model"
+	"survey-api/pkg/auth/password"
 	authrepo "survey-api/pkg/auth/repo"
 	"survey-api/pkg/auth/token"
+	"survey-api/pkg/logger"
+	"survey-api/pkg/metrics"
+	"survey-api/pkg/notification"
+	"survey-api/pkg/storage"
+	"survey-api/pkg/tracing"
 	usermodel "survey-api/pkg/user/model"
 	userrepo "survey-api/pkg/user/repo"
+)
+
+const (
+	userIdKey contextKey = iota
+)
 
-	"golang.org/x/crypto/bcrypt"
+var (
+	ErrInvalidCredentials = apperror.Unauthorized("Invalid credentials")
+	ErrInvalidToken       = apperror.Unauthorized("Invalid token")
+	ErrInvalidSession     = apperror.Unauthorized("Invalid session")
+	ErrUserNameTaken      = apperror.Conflict("User name is already taken").WithField("user_name", "is already taken")
 )
 
+type contextKey int
+
 type Service struct {
-	userRepo      *userrepo.Service
-	authRepo      *authrepo.Service
-	tokenService  *token.Service
-	cookieService *cookie.Service
+	logger              *logger.Service
+	metrics             *metrics.Service
+	tracing             *tracing.Service
+	auditHandler        *audithandler.Service
+	userRepo            userrepo.Repository
+	authRepo            authrepo.Repository
+	tokenService        *token.Service
+	cookieService       *cookie.Service
+	notificationService *notification.Service
+	passwordService     *password.Service
 }
 
 func New(
-	userRepo *userrepo.Service,
-	authRepo *authrepo.Service,
+	logger *logger.Service,
+	metrics *metrics.Service,
+	tracing *tracing.Service,
+	auditHandler *audithandler.Service,
+	userRepo userrepo.Repository,
+	authRepo authrepo.Repository,
 	tokenService *token.Service,
 	cookieService *cookie.Service,
+	notificationService *notification.Service,
+	passwordService *password.Service,
 ) *Service {
 	return &Service{
-		userRepo:      userRepo,
-		authRepo:      authRepo,
-		tokenService:  tokenService,
-		cookieService: cookieService,
+		logger:              logger,
+		metrics:             metrics,
+		tracing:             tracing,
+		auditHandler:        auditHandler,
+		userRepo:            userRepo,
+		authRepo:            authRepo,
+		tokenService:        tokenService,
+		cookieService:       cookieService,
+		notificationService: notificationService,
+		passwordService:     passwordService,
 	}
 }
 
-func (s *Service) Register(registerUser *usermodel.RegisterUser) (*usermodel.User, error) {
+// Register succeeds the same way whether or not the email is already taken,
+// in which case the owner of the existing account is notified instead.
+// User names are public, so a taken one is reported with ErrUserNameTaken.
+func (s *Service) Register(ctx context.Context, registerUser *usermodel.RegisterUser) error {
+	ctx, span := s.tracing.Start(ctx, "authhandler.Register")
+	defer span.End()
+
 	err := registerUser.Validate()
 	if err != nil {
-		return nil, err
+		return apperror.Validation(err)
 	}
 
 	user := registerUser.ToUser()
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordService.Hash(user.Password)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	user.Password = hashedPassword
+	_, err = s.userRepo.InsertOne(ctx, user)
+	var duplicateError *storage.DuplicateError
+	if errors.As(err, &duplicateError) && duplicateError.Field == "email" {
+		s.recordRegistration(ctx, user, auditmodel.OutcomeFailure, "Email is already registered")
+		s.notificationService.SendRegistrationAttempt(user.Email)
+		return nil
+	}
+
+	if errors.As(err, &duplicateError) && duplicateError.Field == "user_name" {
+		s.recordRegistration(ctx, user, auditmodel.OutcomeFailure, "User name is already taken")
+		return ErrUserNameTaken
 	}
 
-	user.Password = string(hashedPassword)
-	user, err = s.userRepo.InsertOne(user)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return user, nil
+	s.recordRegistration(ctx, user, auditmodel.OutcomeSuccess, "")
+	s.metrics.Registered()
+	s.notificationService.SendWelcome(user)
+	return nil
 }
 
-func (s *Service) VerifyUserCredentials(loginUser *usermodel.LoginUser) (*usermodel.User, error) {
+func (s *Service) VerifyUserCredentials(ctx context.Context, loginUser *usermodel.LoginUser) (*usermodel.User, error) {
+	ctx, span := s.tracing.Start(ctx, "authhandler.VerifyUserCredentials")
+	defer span.End()
+
 	err := loginUser.Validate()
 	if err != nil {
-		return nil, err
+		return nil, apperror.Validation(err)
+	}
+
+	user, err := s.userRepo.FindOne(ctx, loginUser.ToUserFilter())
+	if err == storage.ErrNotFound {
+		s.passwordService.VerifyDummy(loginUser.Password)
+		s.metrics.LoggedIn(metrics.LoginFailed)
+		s.auditHandler.Record(ctx, &auditmodel.Event{
+			Action:  auditmodel.ActionLogin,
+			Target:  loginUser.Identifier,
+			Outcome: auditmodel.OutcomeFailure,
+			Reason:  "Unknown user",
+		})
+		return nil, ErrInvalidCredentials
 	}
 
-	user, err := s.userRepo.FindOne(&usermodel.User{UserName: loginUser.UserName})
 	if err != nil {
 		return nil, err
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginUser.Password))
+	ok, err := s.passwordService.Verify(user.Password, loginUser.Password)
 	if err != nil {
 		return nil, err
 	}
 
+	if !ok {
+		s.metrics.LoggedIn(metrics.LoginFailed)
+		s.auditHandler.Record(ctx, &auditmodel.Event{
+			ActorId: user.Id,
+			Action:  auditmodel.ActionLogin,
+			Target:  loginUser.Identifier,
+			Outcome: auditmodel.OutcomeFailure,
+			Reason:  "Wrong password",
+		})
+		return nil, ErrInvalidCredentials
+	}
+
+	s.metrics.LoggedIn(metrics.LoginSucceeded)
+	s.auditHandler.Record(ctx, &auditmodel.Event{
+		ActorId: user.Id,
+		Action:  auditmodel.ActionLogin,
+		Target:  loginUser.Identifier,
+		Outcome: auditmodel.OutcomeSuccess,
+	})
+	logger.AddFields(ctx, logger.Fields{"user_id": user.Id.Hex()})
+	if s.passwordService.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user, loginUser.Password)
+	}
+
 	return user, nil
 }
 
+// A taken email is only recorded, the client is never told about it.
+func (s *Service) recordRegistration(ctx context.Context, user *usermodel.User, outcome string, reason string) {
+	event := &auditmodel.Event{
+		Action:  auditmodel.ActionRegister,
+		Target:  user.UserName,
+		Outcome: outcome,
+		Reason:  reason,
+	}
+	if outcome == auditmodel.OutcomeSuccess {
+		event.ActorId = user.Id
+	}
+
+	s.auditHandler.Record(ctx, event)
+}
+
+// Upgrades the stored hash to the current password policy. This is only
+// possible right after a successful login, while the plain password is
+// known. A failed upgrade is retried on the next login.
+func (s *Service) rehashPassword(ctx context.Context, user *usermodel.User, plainPassword string) {
+	hashedPassword, err := s.passwordService.Hash(plainPassword)
+	if err != nil {
+		s.logger.For(ctx).Error("Failed to rehash the password", logger.Fields{"error": err})
+		return
+	}
+
+	_, err = s.userRepo.UpdateOne(ctx, &usermodel.User{Id: user.Id, Password: hashedPassword})
+	if err != nil {
+		s.logger.For(ctx).Error("Failed to store the rehashed password", logger.Fields{"error": err})
+		return
+	}
+
+	user.Password = hashedPassword
+}
+
 func (s *Service) AuthToken(r *http.Request) (string, error) {
+	_, span := s.tracing.Start(r.Context(), "authhandler.AuthToken")
+	defer span.End()
+
 	token, err := s.tokenService.ParseJwtToken(r)
 	if err != nil {
-		return "", errors.New("Malformed token")
+		return "", ErrInvalidToken
 	}
 
 	userId, err := s.tokenService.ValidateJwtToken(token)
 	if err != nil {
-		return "", err
+		return "", ErrInvalidToken
 	}
 
 	return userId, nil
 }
 
-func (s *Service) GenerateAuth(user *usermodel.User) (*http.Cookie, string, error) {
+// Authenticate is a middleware, which rejects requests without a valid
+// token. The id of the authenticated user is available through UserId.
+func (s *Service) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userId, err := s.AuthToken(r)
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userIdKey, userId)
+		logger.AddFields(ctx, logger.Fields{"user_id": userId})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func UserId(r *http.Request) string {
+	userId, _ := r.Context().Value(userIdKey).(string)
+	return userId
+}
+
+func (s *Service) GenerateAuth(ctx context.Context, user *usermodel.User) (*http.Cookie, string, error) {
+	ctx, span := s.tracing.Start(ctx, "authhandler.GenerateAuth")
+	defer span.End()
+
 	session := &authmodel.Session{
 		UserId: user.Id,
 	}
 	sessionOperation := func(session *authmodel.Session) (*authmodel.Session, error) {
-		return s.authRepo.InsertOne(session)
+		return s.authRepo.InsertOne(ctx, session)
 	}
 
 	return s.generateAuthPair(session, sessionOperation)
 }
 
-func (s *Service) RefreshAuth(session *authmodel.Session) (*http.Cookie, string, error) {
+func (s *Service) RefreshAuth(ctx context.Context, session *authmodel.Session) (*http.Cookie, string, error) {
+	ctx, span := s.tracing.Start(ctx, "authhandler.RefreshAuth")
+	defer span.End()
+
+	logger.AddFields(ctx, logger.Fields{"user_id": session.UserId.Hex()})
 	sessionOperation := func(session *authmodel.Session) (*authmodel.Session, error) {
-		return s.authRepo.ReplaceOne(session)
+		return s.authRepo.ReplaceOne(ctx, session)
 	}
 
-	return s.generateAuthPair(session, sessionOperation)
+	cookie, token, err := s.generateAuthPair(session, sessionOperation)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.metrics.Refreshed()
+	return cookie, token, nil
 }
 
 func (s *Service) generateAuthPair(
@@ -0,0 +1,103 @@
+package password
+
+import (
+	"errors"
+	"survey-api/pkg/config"
+)
+
+const (
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+
+	// Compared against when the user does not exist.
+	dummyPassword = "survey-api-dummy-password"
+)
+
+var (
+	ErrUnknownHash = errors.New("Unknown password hash format")
+)
+
+// PasswordHasher hashes passwords into a self-describing string, which
+// carries the algorithm and its parameters next to the salt and the hash.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash string, password string) (bool, error)
+	// NeedsRehash reports whether the hash was created with weaker
+	// parameters than the ones the hasher is configured with.
+	NeedsRehash(hash string) bool
+	Supports(hash string) bool
+}
+
+// Service hashes new passwords with the hasher selected by the current
+// policy, but still verifies hashes created by any other known hasher.
+type Service struct {
+	current   PasswordHasher
+	hashers   []PasswordHasher
+	dummyHash string
+}
+
+func New(config *config.Config) *Service {
+	params := DefaultArgon2idParams
+	params.Memory = uint32(config.Password.Argon2Memory)
+	params.Iterations = uint32(config.Password.Argon2Iterations)
+	params.Parallelism = uint8(config.Password.Argon2Parallelism)
+	bcryptHasher := NewBcrypt(config.Password.BcryptCost)
+	argon2idHasher := NewArgon2id(params)
+	if config.Password.Algorithm == Argon2id {
+		return NewService(argon2idHasher, bcryptHasher)
+	}
+
+	return NewService(bcryptHasher, argon2idHasher)
+}
+
+// NewService uses current for new hashes, while the legacy hashers are
+// only used to verify hashes that were created before the policy changed.
+// The dummy hash is created up front, so that the first login of an unknown
+// user does not take longer than the others.
+func NewService(current PasswordHasher, legacy ...PasswordHasher) *Service {
+	dummyHash, _ := current.Hash(dummyPassword)
+	return &Service{
+		current:   current,
+		hashers:   append([]PasswordHasher{current}, legacy...),
+		dummyHash: dummyHash,
+	}
+}
+
+func (s *Service) Hash(password string) (string, error) {
+	return s.current.Hash(password)
+}
+
+func (s *Service) Verify(hash string, password string) (bool, error) {
+	for _, hasher := range s.hashers {
+		if hasher.Supports(hash) {
+			return hasher.Verify(hash, password)
+		}
+	}
+
+	return false, ErrUnknownHash
+}
+
+func (s *Service) NeedsRehash(hash string) bool {
+	if !s.current.Supports(hash) {
+		return true
+	}
+
+	return s.current.NeedsRehash(hash)
+}
+
+func (s *Service) Supports(hash string) bool {
+	for _, hasher := range s.hashers {
+		if hasher.Supports(hash) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// VerifyDummy does the same amount of work as verifying a real password
+// with the current policy, so that unknown users cannot be told apart
+// from wrong passwords by the response time.
+func (s *Service) VerifyDummy(password string) {
+	s.current.Verify(s.dummyHash, password)
+}
@@ -0,0 +1,183 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// Cheap parameters, so that the tests run fast.
+	testArgon2idParams = Argon2idParams{
+		Memory:      64,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+)
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	hasher := NewArgon2id(testArgon2idParams)
+	hash, err := hasher.Hash("Secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") || !hasher.Supports(hash) {
+		t.Fatalf("expected a PHC string of the parameters, got %s", hash)
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *params != testArgon2idParams || len(salt) != 16 || len(key) != 32 {
+		t.Fatalf("expected the encoded parameters, got %+v", params)
+	}
+
+	ok, err := hasher.Verify(hash, "Secret123")
+	if err != nil || !ok {
+		t.Fatalf("expected the password to match, got %t and %v", ok, err)
+	}
+
+	ok, err = hasher.Verify(hash, "Secret124")
+	if err != nil || ok {
+		t.Fatalf("expected another password not to match, got %t and %v", ok, err)
+	}
+
+	other, err := hasher.Hash("Secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other == hash {
+		t.Fatal("expected every hash to have its own salt")
+	}
+}
+
+func TestDecodeArgon2idRejectsMalformedHashes(t *testing.T) {
+	salt := "c2FsdHNhbHRzYWx0c2FsdA"
+	key := "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+	tests := map[string]string{
+		"missing part":      "$argon2id$v=19$m=64,t=1,p=1$" + salt,
+		"other version":     "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key,
+		"no iterations":     "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key,
+		"no parallelism":    "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key,
+		"malformed params":  "$argon2id$v=19$m=64;t=1;p=1$" + salt + "$" + key,
+		"malformed salt":    "$argon2id$v=19$m=64,t=1,p=1$!!!$" + key,
+		"malformed key":     "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$!!!",
+		"empty key":         "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$",
+		"empty hash string": "",
+	}
+	hasher := NewArgon2id(testArgon2idParams)
+	for name, hash := range tests {
+		_, _, _, err := decodeArgon2id(hash)
+		if err != errMalformedArgon2idHash {
+			t.Errorf("%s: expected a malformed hash, got %v", name, err)
+		}
+
+		ok, err := hasher.Verify(hash, "Secret123")
+		if ok || err == nil {
+			t.Errorf("%s: expected the verification to fail", name)
+		}
+	}
+}
+
+func TestArgon2idNeedsRehash(t *testing.T) {
+	hash, err := NewArgon2id(testArgon2idParams).Hash("Secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withParams := func(change func(*Argon2idParams)) PasswordHasher {
+		params := testArgon2idParams
+		change(&params)
+		return NewArgon2id(params)
+	}
+	tests := []struct {
+		name     string
+		hasher   PasswordHasher
+		expected bool
+	}{
+		{"same parameters", NewArgon2id(testArgon2idParams), false},
+		{"weaker parameters", withParams(func(p *Argon2idParams) { p.Memory = 32 }), false},
+		{"more memory", withParams(func(p *Argon2idParams) { p.Memory = 128 }), true},
+		{"more iterations", withParams(func(p *Argon2idParams) { p.Iterations = 2 }), true},
+		{"more parallelism", withParams(func(p *Argon2idParams) { p.Parallelism = 2 }), true},
+		{"longer key", withParams(func(p *Argon2idParams) { p.KeyLength = 64 }), true},
+	}
+	for _, test := range tests {
+		if test.hasher.NeedsRehash(hash) != test.expected {
+			t.Errorf("%s: expected NeedsRehash to be %t", test.name, test.expected)
+		}
+	}
+
+	if !NewArgon2id(testArgon2idParams).NeedsRehash("$argon2id$malformed") {
+		t.Error("expected a malformed hash to need a rehash")
+	}
+}
+
+func TestServiceVerifiesLegacyHashes(t *testing.T) {
+	bcryptHasher := NewBcrypt(bcrypt.MinCost)
+	argon2idHasher := NewArgon2id(testArgon2idParams)
+	service := NewService(argon2idHasher, bcryptHasher)
+	legacyHash, err := bcryptHasher.Hash("Secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := service.Verify(legacyHash, "Secret123")
+	if err != nil || !ok {
+		t.Fatalf("expected the legacy hash to match, got %t and %v", ok, err)
+	}
+
+	ok, err = service.Verify(legacyHash, "Secret124")
+	if err != nil || ok {
+		t.Fatalf("expected another password not to match the legacy hash, got %t and %v", ok, err)
+	}
+
+	if !service.NeedsRehash(legacyHash) {
+		t.Error("expected a legacy hash to need a rehash")
+	}
+
+	hash, err := service.Hash("Secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !argon2idHasher.Supports(hash) || service.NeedsRehash(hash) {
+		t.Errorf("expected a current hash of the current hasher, got %s", hash)
+	}
+
+	_, err = service.Verify("$1$unknown", "Secret123")
+	if err != ErrUnknownHash {
+		t.Errorf("expected an unknown hash, got %v", err)
+	}
+}
+
+func TestServiceCreatesDummyHashUpFront(t *testing.T) {
+	argon2idHasher := NewArgon2id(testArgon2idParams)
+	service := NewService(argon2idHasher, NewBcrypt(bcrypt.MinCost))
+	if !argon2idHasher.Supports(service.dummyHash) || argon2idHasher.NeedsRehash(service.dummyHash) {
+		t.Fatalf("expected a dummy hash of the current hasher, got %q", service.dummyHash)
+	}
+
+	ok, err := service.Verify(service.dummyHash, dummyPassword)
+	if err != nil || !ok {
+		t.Errorf("expected the dummy hash to cost a full verification, got %t and %v", ok, err)
+	}
+}
+
+func TestBcryptNeedsRehash(t *testing.T) {
+	hash, err := NewBcrypt(bcrypt.MinCost).Hash("Secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if NewBcrypt(bcrypt.MinCost).NeedsRehash(hash) || !NewBcrypt(bcrypt.MinCost+1).NeedsRehash(hash) {
+		t.Error("expected only a higher cost to need a rehash")
+	}
+}
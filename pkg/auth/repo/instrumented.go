@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"context"
+	"survey-api/pkg/auth/model"
+	"survey-api/pkg/metrics"
+	"survey-api/pkg/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/semconv"
+)
+
+// Instrumented measures the duration of every operation of a Repository
+// and traces it.
+type Instrumented struct {
+	next    Repository
+	observe func(method string, start time.Time, err error)
+	tracing *tracing.Service
+	storage string
+}
+
+func NewInstrumented(next Repository, metrics *metrics.Service, tracingService *tracing.Service, storage string) *Instrumented {
+	return &Instrumented{
+		next:    next,
+		observe: metrics.StorageObserver(storage, "session"),
+		tracing: tracingService,
+		storage: storage,
+	}
+}
+
+// The returned function ends the operation.
+func (i *Instrumented) begin(ctx context.Context, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := i.tracing.Start(ctx, "session."+method,
+		semconv.DBSystemKey.String(i.storage),
+		semconv.DBOperationKey.String(method),
+	)
+	return ctx, func(err error) {
+		tracing.End(span, err)
+		i.observe(method, start, err)
+	}
+}
+
+func (i *Instrumented) InsertOne(ctx context.Context, session *model.Session) (*model.Session, error) {
+	ctx, end := i.begin(ctx, "InsertOne")
+	session, err := i.next.InsertOne(ctx, session)
+	end(err)
+	return session, err
+}
+
+func (i *Instrumented) FindById(ctx context.Context, sessionId string) (*model.Session, error) {
+	ctx, end := i.begin(ctx, "FindById")
+	session, err := i.next.FindById(ctx, sessionId)
+	end(err)
+	return session, err
+}
+
+func (i *Instrumented) FindOne(ctx context.Context, sessionFilter *model.Session) (*model.Session, error) {
+	ctx, end := i.begin(ctx, "FindOne")
+	session, err := i.next.FindOne(ctx, sessionFilter)
+	end(err)
+	return session, err
+}
+
+func (i *Instrumented) ReplaceOne(ctx context.Context, session *model.Session) (*model.Session, error) {
+	ctx, end := i.begin(ctx, "ReplaceOne")
+	session, err := i.next.ReplaceOne(ctx, session)
+	end(err)
+	return session, err
+}
+
+func (i *Instrumented) DeleteOne(ctx context.Context, session *model.Session) error {
+	ctx, end := i.begin(ctx, "DeleteOne")
+	err := i.next.DeleteOne(ctx, session)
+	end(err)
+	return err
+}
@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"context"
+	"survey-api/pkg/auth/model"
+	"survey-api/pkg/storage"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Memory is an in-memory implementation of Repository for tests and local
+// demos. It is safe for concurrent use. Unlike the MongoDB collection, it
+// never expires sessions.
+type Memory struct {
+	mutex    sync.RWMutex
+	sessions map[primitive.ObjectID]model.Session
+}
+
+func NewMemory() *Memory {
+	return &Memory{sessions: make(map[primitive.ObjectID]model.Session)}
+}
+
+func (m *Memory) InsertOne(ctx context.Context, session *model.Session) (*model.Session, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	session.Id = primitive.NewObjectID()
+	session.LastModified = primitive.NewDateTimeFromTime(time.Now().UTC())
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.sessions[session.Id] = *session
+	return session, nil
+}
+
+func (m *Memory) FindById(ctx context.Context, sessionIdString string) (*model.Session, error) {
+	sessionId, err := primitive.ObjectIDFromHex(sessionIdString)
+	if err != nil || sessionId.IsZero() {
+		return nil, storage.ErrNotFound
+	}
+
+	return m.FindOne(ctx, &model.Session{Id: sessionId})
+}
+
+func (m *Memory) FindOne(ctx context.Context, sessionFilter *model.Session) (*model.Session, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for _, session := range m.sessions {
+		if matchesSession(sessionFilter, &session) {
+			return &session, nil
+		}
+	}
+
+	return nil, storage.ErrNotFound
+}
+
+func (m *Memory) ReplaceOne(ctx context.Context, session *model.Session) (*model.Session, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	session.LastModified = primitive.NewDateTimeFromTime(time.Now().UTC())
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, ok := m.sessions[session.Id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	m.sessions[session.Id] = *session
+	return session, nil
+}
+
+func (m *Memory) DeleteOne(ctx context.Context, session *model.Session) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for id, other := range m.sessions {
+		if matchesSession(session, &other) {
+			delete(m.sessions, id)
+			return nil
+		}
+	}
+
+	return storage.ErrNotFound
+}
+
+func matchesSession(filter *model.Session, session *model.Session) bool {
+	return (filter.Id.IsZero() || filter.Id == session.Id) &&
+		(filter.UserId.IsZero() || filter.UserId == session.UserId) &&
+		(len(filter.Token) == 0 || filter.Token == session.Token) &&
+		(filter.LastModified == 0 || filter.LastModified == session.LastModified)
+}
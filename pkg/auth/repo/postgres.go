@@ -0,0 +1,148 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"survey-api/pkg/auth/model"
+	"survey-api/pkg/config"
+	"survey-api/pkg/postgres"
+	"survey-api/pkg/storage"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	sessionColumns = "id, user_id, token, last_modified"
+	// Matches the TTL index of the MongoDB collection. Expired sessions are
+	// ignored by every query and deleted with the next session of the user.
+	sessionValidity = 12 * time.Hour
+)
+
+// Postgres is the PostgreSQL implementation of Repository.
+type Postgres struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewPostgres(db *sql.DB, config *config.Config) *Postgres {
+	return &Postgres{db: db, timeout: config.Postgres.OperationTimeout}
+}
+
+func (p *Postgres) InsertOne(ctx context.Context, session *model.Session) (*model.Session, error) {
+	session.Id = primitive.NewObjectID()
+	session.LastModified = primitive.NewDateTimeFromTime(time.Now().UTC())
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	_, err := p.db.ExecContext(ctx, "DELETE FROM sessions WHERE user_id = $1 AND last_modified <= $2",
+		session.UserId.Hex(), expiredBefore())
+	if err != nil {
+		return nil, storage.FromPostgres(err, nil)
+	}
+
+	_, err = p.db.ExecContext(ctx, "INSERT INTO sessions ("+sessionColumns+") VALUES ($1, $2, $3, $4)",
+		session.Id.Hex(), session.UserId.Hex(), session.Token, session.LastModified.Time().UTC())
+	if err != nil {
+		return nil, storage.FromPostgres(err, nil)
+	}
+
+	return session, nil
+}
+
+func (p *Postgres) FindById(ctx context.Context, sessionIdString string) (*model.Session, error) {
+	sessionId, err := primitive.ObjectIDFromHex(sessionIdString)
+	if err != nil || sessionId.IsZero() {
+		return nil, storage.ErrNotFound
+	}
+
+	return p.FindOne(ctx, &model.Session{Id: sessionId})
+}
+
+func (p *Postgres) FindOne(ctx context.Context, sessionFilter *model.Session) (*model.Session, error) {
+	var params postgres.Params
+	conditions := sessionConditions(&params, sessionFilter)
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	row := p.db.QueryRowContext(ctx, "SELECT "+sessionColumns+" FROM sessions"+postgres.Where(conditions)+" LIMIT 1", params.Args()...)
+	var session model.Session
+	var id, userId string
+	var lastModified time.Time
+	err := row.Scan(&id, &userId, &session.Token, &lastModified)
+	if err != nil {
+		return nil, storage.FromPostgres(err, nil)
+	}
+
+	session.LastModified = primitive.NewDateTimeFromTime(lastModified)
+	session.Id, err = postgres.ObjectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	session.UserId, err = postgres.ObjectID(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (p *Postgres) ReplaceOne(ctx context.Context, session *model.Session) (*model.Session, error) {
+	session.LastModified = primitive.NewDateTimeFromTime(time.Now().UTC())
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	result, err := p.db.ExecContext(ctx, "UPDATE sessions SET user_id = $1, token = $2, last_modified = $3 WHERE id = $4 AND last_modified > $5",
+		session.UserId.Hex(), session.Token, session.LastModified.Time().UTC(), session.Id.Hex(), expiredBefore())
+	if err != nil {
+		return nil, storage.FromPostgres(err, nil)
+	}
+
+	err = postgres.ExpectRows(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (p *Postgres) DeleteOne(ctx context.Context, session *model.Session) error {
+	var params postgres.Params
+	conditions := sessionConditions(&params, session)
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	statement := "DELETE FROM sessions WHERE id = (SELECT id FROM sessions" + postgres.Where(conditions) + " LIMIT 1)"
+	result, err := p.db.ExecContext(ctx, statement, params.Args()...)
+	if err != nil {
+		return storage.FromPostgres(err, nil)
+	}
+
+	return postgres.ExpectRows(result)
+}
+
+func sessionConditions(params *postgres.Params, filter *model.Session) []string {
+	conditions := []string{"last_modified > " + params.Add(expiredBefore())}
+	if !filter.Id.IsZero() {
+		conditions = append(conditions, "id = "+params.Add(filter.Id.Hex()))
+	}
+
+	if !filter.UserId.IsZero() {
+		conditions = append(conditions, "user_id = "+params.Add(filter.UserId.Hex()))
+	}
+
+	if len(filter.Token) != 0 {
+		conditions = append(conditions, "token = "+params.Add(filter.Token))
+	}
+
+	if filter.LastModified != 0 {
+		conditions = append(conditions, "last_modified = "+params.Add(filter.LastModified.Time().UTC()))
+	}
+
+	return conditions
+}
+
+func expiredBefore() time.Time {
+	return time.Now().UTC().Add(-sessionValidity)
+}
@@ -3,62 +3,69 @@ package repo
 import (
 	"context"
 	"survey-api/pkg/auth/model"
+	"survey-api/pkg/config"
+	"survey-api/pkg/storage"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var (
-	sessionValiditySeconds = (time.Hour * time.Duration(12)).Seconds()
-)
+// Repository stores the sessions. Missing sessions are reported with
+// storage.ErrNotFound.
+type Repository interface {
+	InsertOne(ctx context.Context, session *model.Session) (*model.Session, error)
+	FindById(ctx context.Context, sessionId string) (*model.Session, error)
+	// FindOne matches the non-zero fields of the filter.
+	FindOne(ctx context.Context, sessionFilter *model.Session) (*model.Session, error)
+	ReplaceOne(ctx context.Context, session *model.Session) (*model.Session, error)
+	// DeleteOne deletes a session, which matches the non-zero fields of the
+	// given one.
+	DeleteOne(ctx context.Context, session *model.Session) error
+}
 
+// Service is the MongoDB implementation of Repository.
 type Service struct {
-	client *mongo.Client
+	db      *mongo.Database
+	timeout time.Duration
 }
 
-func New(client *mongo.Client) (*Service, error) {
-	repo := &Service{client: client}
-	err := repo.createUserIndexes()
-	if err != nil {
-		return nil, err
-	}
-
-	return repo, nil
+func New(db *mongo.Database, config *config.Config) *Service {
+	return &Service{db: db, timeout: config.Mongodb.OperationTimeout}
 }
 
-func (s *Service) InsertOne(session *model.Session) (*model.Session, error) {
+func (s *Service) InsertOne(ctx context.Context, session *model.Session) (*model.Session, error) {
 	session.Id = primitive.NewObjectID()
 	session.LastModified = primitive.NewDateTimeFromTime(time.Now().UTC())
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	_, err := s.sessionCollection().InsertOne(ctx, session)
 	defer cancel()
 	if err != nil {
-		return nil, err
+		return nil, storage.FromMongo(err, nil)
 	}
 
 	return session, nil
 }
 
-func (s *Service) FindById(sessionIdString string) (*model.Session, error) {
+func (s *Service) FindById(ctx context.Context, sessionIdString string) (*model.Session, error) {
 	sessionId, err := primitive.ObjectIDFromHex(sessionIdString)
-	if err != nil {
-		return nil, err
+	// A zero id is left out of the filter, which would then match any
+	// document.
+	if err != nil || sessionId.IsZero() {
+		return nil, storage.ErrNotFound
 	}
 
-	return s.FindOne(&model.Session{Id: sessionId})
+	return s.FindOne(ctx, &model.Session{Id: sessionId})
 }
 
-func (s *Service) FindOne(sessionFilter *model.Session) (*model.Session, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (s *Service) FindOne(ctx context.Context, sessionFilter *model.Session) (*model.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	result := s.sessionCollection().FindOne(ctx, sessionFilter)
 	defer cancel()
 	err := result.Err()
 	if err != nil {
-		return nil, err
+		return nil, storage.FromMongo(err, nil)
 	}
 
 	var session *model.Session
@@ -70,55 +77,39 @@ func (s *Service) FindOne(sessionFilter *model.Session) (*model.Session, error)
 	return session, nil
 }
 
-func (s *Service) ReplaceOne(session *model.Session) (*model.Session, error) {
+func (s *Service) ReplaceOne(ctx context.Context, session *model.Session) (*model.Session, error) {
 	session.LastModified = primitive.NewDateTimeFromTime(time.Now().UTC())
 	sessionFilter := &model.Session{Id: session.Id}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	_, err := s.sessionCollection().ReplaceOne(ctx, sessionFilter, session)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	result, err := s.sessionCollection().ReplaceOne(ctx, sessionFilter, session)
 	defer cancel()
 	if err != nil {
-		return nil, err
+		return nil, storage.FromMongo(err, nil)
+	}
+
+	if result.MatchedCount == 0 {
+		return nil, storage.ErrNotFound
 	}
 
 	return session, nil
 }
 
-func (s *Service) DeleteOne(session *model.Session) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	_, err := s.sessionCollection().DeleteOne(ctx, session)
+func (s *Service) DeleteOne(ctx context.Context, session *model.Session) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	result, err := s.sessionCollection().DeleteOne(ctx, session)
 	defer cancel()
 	if err != nil {
-		return err
+		return storage.FromMongo(err, nil)
+	}
+
+	if result.DeletedCount == 0 {
+		return storage.ErrNotFound
 	}
 
 	return nil
 }
 
 func (s *Service) sessionCollection() *mongo.Collection {
-	return s.client.Database("survey").Collection("session")
-}
-
-func (s *Service) createUserIndexes() error {
-	collection := s.sessionCollection()
-	indexes := []mongo.IndexModel{
-		{
-			Keys: bson.M{"last_modified": 1},
-			Options: options.Index().SetExpireAfterSeconds(
-				int32(sessionValiditySeconds),
-			),
-		},
-		{
-			Keys: bson.M{"token": "text"},
-		},
-	}
-
-	context, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	_, err := collection.Indexes().CreateMany(context, indexes)
-	defer cancel()
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return s.db.Collection("session")
 }
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+	"survey-api/pkg/auth/model"
+	"survey-api/pkg/mongodb/mongodbtest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOperationsFollowContext(t *testing.T) {
+	timeout := 50 * time.Millisecond
+	s := &Service{db: mongodbtest.Unreachable(t), timeout: timeout}
+	session := &model.Session{Id: primitive.NewObjectID(), UserId: primitive.NewObjectID(), Token: "token"}
+	mongodbtest.TestCancellation(t, timeout, map[string]mongodbtest.Operation{
+		"InsertOne": func(ctx context.Context) error {
+			_, err := s.InsertOne(ctx, session)
+			return err
+		},
+		"FindById": func(ctx context.Context) error {
+			_, err := s.FindById(ctx, session.Id.Hex())
+			return err
+		},
+		"FindOne": func(ctx context.Context) error {
+			_, err := s.FindOne(ctx, &model.Session{Token: session.Token})
+			return err
+		},
+		"ReplaceOne": func(ctx context.Context) error {
+			_, err := s.ReplaceOne(ctx, session)
+			return err
+		},
+		"DeleteOne": func(ctx context.Context) error {
+			return s.DeleteOne(ctx, session)
+		},
+	})
+}
@@ -0,0 +1,162 @@
+// Package repotest is the contract every implementation of the session
+// repository has to satisfy.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"survey-api/pkg/auth/model"
+	"survey-api/pkg/auth/repo"
+	"survey-api/pkg/storage"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Storage is a new, empty repository for a test. Sessions reference
+// users, so NewUserId stores a user next to the repository and returns its id.
+type Storage struct {
+	repo.Repository
+	NewUserId func(t *testing.T) primitive.ObjectID
+}
+
+// AnyUserId is the NewUserId of storages, which do not check references.
+func AnyUserId(t *testing.T) primitive.ObjectID {
+	return primitive.NewObjectID()
+}
+
+// Run runs the contract against a new storage for every test.
+func Run(t *testing.T, newStorage func(t *testing.T) *Storage) {
+	tests := map[string]func(t *testing.T, r *Storage){
+		"InsertAndFind":    testInsertAndFind,
+		"NotFound":         testNotFound,
+		"Replace":          testReplace,
+		"ReplaceNotFound":  testReplaceNotFound,
+		"Delete":           testDelete,
+		"CancelledContext": testCancelledContext,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, newStorage(t))
+		})
+	}
+}
+
+func insert(t *testing.T, r *Storage, token string) *model.Session {
+	t.Helper()
+	session, err := r.InsertOne(context.Background(), &model.Session{UserId: r.NewUserId(t), Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return session
+}
+
+func testInsertAndFind(t *testing.T, r *Storage) {
+	session := insert(t, r, "token")
+	if session.Id.IsZero() || session.LastModified == 0 {
+		t.Fatalf("expected an id and a modification time, got %+v", session)
+	}
+
+	found, err := r.FindById(context.Background(), session.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *found != *session {
+		t.Fatalf("expected %+v, got %+v", session, found)
+	}
+
+	found, err = r.FindOne(context.Background(), &model.Session{Token: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.Id != session.Id {
+		t.Fatalf("expected %v, got %v", session.Id, found.Id)
+	}
+}
+
+func testNotFound(t *testing.T, r *Storage) {
+	insert(t, r, "token")
+
+	_, err := r.FindById(context.Background(), primitive.NewObjectID().Hex())
+	if err != storage.ErrNotFound {
+		t.Fatalf("unknown id: expected %v, got %v", storage.ErrNotFound, err)
+	}
+
+	_, err = r.FindById(context.Background(), "invalid")
+	if err != storage.ErrNotFound {
+		t.Fatalf("invalid id: expected %v, got %v", storage.ErrNotFound, err)
+	}
+
+	_, err = r.FindById(context.Background(), primitive.NilObjectID.Hex())
+	if err != storage.ErrNotFound {
+		t.Fatalf("zero id: expected %v, got %v", storage.ErrNotFound, err)
+	}
+
+	_, err = r.FindOne(context.Background(), &model.Session{Token: "other"})
+	if err != storage.ErrNotFound {
+		t.Fatalf("unknown token: expected %v, got %v", storage.ErrNotFound, err)
+	}
+}
+
+func testReplace(t *testing.T, r *Storage) {
+	session := insert(t, r, "token")
+	session.Token = "refreshed"
+	_, err := r.ReplaceOne(context.Background(), session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := r.FindById(context.Background(), session.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.Token != "refreshed" {
+		t.Fatalf("expected the refreshed token, got %s", found.Token)
+	}
+}
+
+func testReplaceNotFound(t *testing.T, r *Storage) {
+	session := &model.Session{Id: primitive.NewObjectID(), UserId: primitive.NewObjectID(), Token: "token"}
+	_, err := r.ReplaceOne(context.Background(), session)
+	if err != storage.ErrNotFound {
+		t.Fatalf("expected %v, got %v", storage.ErrNotFound, err)
+	}
+}
+
+func testDelete(t *testing.T, r *Storage) {
+	session := insert(t, r, "token")
+	other := insert(t, r, "other")
+	err := r.DeleteOne(context.Background(), session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.FindById(context.Background(), session.Id.Hex())
+	if err != storage.ErrNotFound {
+		t.Fatalf("expected %v, got %v", storage.ErrNotFound, err)
+	}
+
+	_, err = r.FindById(context.Background(), other.Id.Hex())
+	if err != nil {
+		t.Fatalf("expected the other session to be kept, got %v", err)
+	}
+
+	err = r.DeleteOne(context.Background(), session)
+	if err != storage.ErrNotFound {
+		t.Fatalf("expected %v, got %v", storage.ErrNotFound, err)
+	}
+}
+
+func testCancelledContext(t *testing.T, r *Storage) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := r.InsertOne(ctx, &model.Session{UserId: primitive.NewObjectID(), Token: "token"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -3,8 +3,8 @@ package token
 import (
 	"errors"
 	"net/http"
-	"os"
 	"strings"
+	"survey-api/pkg/config"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -17,10 +17,11 @@ const (
 )
 
 type Service struct {
+	config *config.Config
 }
 
-func New() *Service {
-	return &Service{}
+func New(config *config.Config) *Service {
+	return &Service{config: config}
 }
 
 func (s *Service) ParseJwtToken(r *http.Request) (string, error) {
@@ -42,12 +43,7 @@ func (s *Service) GenerateJwtToken(userId string) (string, error) {
 		Subject:   userId,
 		ExpiresAt: time.Now().Add(jwtTokenValidityMins).UTC().Unix(),
 	})
-	jwtKey := os.Getenv("JWT_KEY")
-	if len(jwtKey) == 0 {
-		return "", errors.New("JWT_KEY is not set")
-	}
-
-	tokenString, err := token.SignedString([]byte(jwtKey))
+	tokenString, err := token.SignedString([]byte(s.config.Auth.JwtKey))
 	if err != nil {
 		return "", err
 	}
@@ -56,11 +52,6 @@ func (s *Service) GenerateJwtToken(userId string) (string, error) {
 }
 
 func (s *Service) ValidateJwtToken(tokenString string) (string, error) {
-	jwtKey := os.Getenv("JWT_KEY")
-	if len(jwtKey) == 0 {
-		return "", errors.New("JWT_KEY is not set")
-	}
-
 	claims := &jwt.StandardClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		_, ok := token.Method.(*jwt.SigningMethodHMAC)
@@ -68,7 +59,7 @@ func (s *Service) ValidateJwtToken(tokenString string) (string, error) {
 			return nil, errors.New("Unexpected signing method: " + token.Method.Alg())
 		}
 
-		return []byte(jwtKey), nil
+		return []byte(s.config.Auth.JwtKey), nil
 	})
 	if err != nil {
 		return "", err
@@ -0,0 +1,551 @@
+// Package config loads every setting of the application once, at startup.
+//
+// Settings are read from the defaults, then from the optional JSON file
+// named by CONFIG_FILE and finally from the environment. The file uses the
+// names of the environment variables as keys, for example:
+//
+//	{"JWT_KEY": "...", "SERVER_WRITE_TIMEOUT": "30s"}
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	fileEnv = "CONFIG_FILE"
+
+	MongodbStorage  = "mongodb"
+	PostgresStorage = "postgres"
+	// Keeps everything in memory, for tests and local demos.
+	MemoryStorage = "memory"
+
+	NoTracing     = "none"
+	StdoutTracing = "stdout"
+	OtlpTracing   = "otlp"
+)
+
+type Config struct {
+	Storage   string
+	Log       Log
+	Server    Server
+	Mongodb   Mongodb
+	Postgres  Postgres
+	Auth      Auth
+	Password  Password
+	Smtp      Smtp
+	Metrics   Metrics
+	Tracing   Tracing
+	Admin     Admin
+	RateLimit RateLimit
+	Cors      Cors
+	Security  Security
+	Cookie    Cookie
+}
+
+type Log struct {
+	// The lowest level written, one of debug, info, warn and error.
+	Level string
+}
+
+type Server struct {
+	Host              string
+	Port              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	TLSCertFile       string
+	TLSKeyFile        string
+	MaxBodyBytes      int64
+	// The comma-separated addresses or CIDR ranges of the reverse proxies,
+	// like 10.0.0.0/8, whose X-Forwarded-For and X-Real-IP headers name
+	// the client. Empty trusts no proxy, so that the peer of the connection
+	// is the client. Behind a proxy, which is not trusted, every client
+	// shares the address of the proxy, and with it the rate limits.
+	TrustedProxies string
+}
+
+type Mongodb struct {
+	// The full connection string, which takes precedence over the host,
+	// port, user and password, for example a mongodb+srv:// URI.
+	Uri        string
+	Host       string
+	Port       string
+	User       string
+	Password   string
+	AuthSource string // Only used without a URI, which carries its own authSource.
+	Database   string
+	ReplicaSet string
+	// PEM files for TLS, which is enabled by setting either of them.
+	TLSCAFile             string
+	TLSCertificateKeyFile string
+	// Zero values keep the defaults of the driver, or the values of the URI.
+	MaxPoolSize            int
+	MinPoolSize            int
+	MaxConnIdleTime        time.Duration
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	// The deadline of a single repository operation, within the deadline
+	// of the request.
+	OperationTimeout time.Duration
+}
+
+type Postgres struct {
+	// A postgres:// URL, with the parameters of lib/pq, like sslmode.
+	Url string
+	// Zero values keep the defaults of database/sql.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	// The deadline of a single repository operation, within the deadline
+	// of the request.
+	OperationTimeout time.Duration
+}
+
+type Auth struct {
+	JwtKey     string
+	SessionKey string
+}
+
+type Password struct {
+	Algorithm         string
+	BcryptCost        int
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+}
+
+type Smtp struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	From     string
+}
+
+type Metrics struct {
+	// When set, scrapes of /metrics must send it as a bearer token.
+	Token string
+}
+
+type Tracing struct {
+	// The exporter of the spans, one of none, stdout and otlp.
+	Exporter string
+	// The OTLP/HTTP collector, like http://localhost:55681. Spans are sent
+	// in plain text unless the scheme is https.
+	OtlpEndpoint string
+}
+
+type Admin struct {
+	// The comma-separated ids of the users, who may read the audit log.
+	UserIds string
+}
+
+type RateLimit struct {
+	// Where the buckets are kept, one of memory and mongodb. Only the
+	// mongodb store shares the limits between instances and serverless
+	// functions.
+	Store string
+	// The comma-separated limits of the routes, like "POST /register=5/1m"
+	// for 5 requests per minute and client. An empty value disables rate
+	// limiting.
+	Limits string
+}
+
+type Cors struct {
+	// The comma-separated origins of the browser clients, like
+	// https://survey.example.com, or * for any origin. Empty disables CORS.
+	AllowedOrigins string
+	// Lets the browsers of the origins send the session cookie. Not allowed
+	// with any origin.
+	AllowCredentials bool
+	// How long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+type Security struct {
+	// The max-age of Strict-Transport-Security. Zero leaves the header
+	// out, for deployments which are not only served over HTTPS.
+	HstsMaxAge time.Duration
+}
+
+type Cookie struct {
+	// The SameSite policy of the session cookie, one of strict, lax and
+	// none. Clients of another site need none, with AllowCredentials.
+	SameSite string
+	// Shares the cookie with the subdomains of the domain. Empty keeps it
+	// to the host of the API.
+	Domain string
+}
+
+// RouteLimit allows bursts of Requests, which are refilled evenly over
+// Period.
+type RouteLimit struct {
+	Method   string
+	Pattern  string
+	Requests int
+	Period   time.Duration
+}
+
+// Error reports every invalid setting at once.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "Invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+type binding struct {
+	key    string
+	target interface{}
+}
+
+// Default returns the configuration without any file or environment.
+func Default() *Config {
+	return &Config{
+		Storage: MongodbStorage,
+		Log: Log{
+			Level: "info",
+		},
+		Server: Server{
+			Host:              "localhost",
+			Port:              "3000",
+			ReadTimeout:       10 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      15 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+			MaxBodyBytes:      1 << 20,
+		},
+		Mongodb: Mongodb{
+			Host:             "localhost",
+			Port:             "27017",
+			Database:         "survey",
+			OperationTimeout: 3 * time.Second,
+		},
+		Postgres: Postgres{
+			OperationTimeout: 3 * time.Second,
+		},
+		Password: Password{
+			Algorithm:         "bcrypt",
+			BcryptCost:        10,
+			Argon2Memory:      19 * 1024,
+			Argon2Iterations:  2,
+			Argon2Parallelism: 1,
+		},
+		Smtp: Smtp{
+			Port: "587",
+			From: "no-reply@survey-api",
+		},
+		Tracing: Tracing{
+			Exporter:     NoTracing,
+			OtlpEndpoint: "http://localhost:55681",
+		},
+		RateLimit: RateLimit{
+			Store:  MemoryStorage,
+			Limits: "POST /register=5/1m, POST /poll=20/1m, PUT /poll/vote=60/1m",
+		},
+		Cors: Cors{
+			MaxAge: 10 * time.Minute,
+		},
+		Security: Security{
+			HstsMaxAge: 365 * 24 * time.Hour,
+		},
+		Cookie: Cookie{
+			SameSite: "strict",
+		},
+	}
+}
+
+// Load loads and validates the configuration.
+func Load() (*Config, error) {
+	values, err := readFile(os.Getenv(fileEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	// A variable, which is set but empty, overrides as well, so that the
+	// environment alone can clear a value, like RATE_LIMITS.
+	for _, env := range os.Environ() {
+		pair := strings.SplitN(env, "=", 2)
+		values[pair[0]] = pair[1]
+	}
+
+	return FromValues(values)
+}
+
+// FromValues builds the configuration from the defaults overridden by the
+// given values, keyed by the names of the environment variables.
+func FromValues(values map[string]string) (*Config, error) {
+	config := Default()
+	var problems []string
+	for _, binding := range config.bindings() {
+		value, ok := values[binding.key]
+		if !ok {
+			continue
+		}
+
+		err := parse(value, binding.target)
+		if err != nil {
+			problems = append(problems, binding.key+" "+err.Error())
+		}
+	}
+
+	problems = append(problems, config.validate()...)
+	if len(problems) != 0 {
+		return nil, &Error{Problems: problems}
+	}
+
+	return config, nil
+}
+
+func (c *Config) bindings() []binding {
+	return []binding{
+		{"STORAGE", &c.Storage},
+		{"LOG_LEVEL", &c.Log.Level},
+		{"HOST", &c.Server.Host},
+		{"PORT", &c.Server.Port},
+		{"SERVER_READ_TIMEOUT", &c.Server.ReadTimeout},
+		{"SERVER_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout},
+		{"SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout},
+		{"TLS_CERT_FILE", &c.Server.TLSCertFile},
+		{"TLS_KEY_FILE", &c.Server.TLSKeyFile},
+		{"MAX_BODY_BYTES", &c.Server.MaxBodyBytes},
+		{"TRUSTED_PROXIES", &c.Server.TrustedProxies},
+		{"MONGODB_URI", &c.Mongodb.Uri},
+		{"MONGODB_HOST", &c.Mongodb.Host},
+		{"MONGODB_PORT", &c.Mongodb.Port},
+		{"MONGODB_USER", &c.Mongodb.User},
+		{"MONGODB_PASSWORD", &c.Mongodb.Password},
+		{"MONGODB_AUTH_SOURCE", &c.Mongodb.AuthSource},
+		{"MONGODB_DATABASE", &c.Mongodb.Database},
+		{"MONGODB_REPLICA_SET", &c.Mongodb.ReplicaSet},
+		{"MONGODB_TLS_CA_FILE", &c.Mongodb.TLSCAFile},
+		{"MONGODB_TLS_CERTIFICATE_KEY_FILE", &c.Mongodb.TLSCertificateKeyFile},
+		{"MONGODB_MAX_POOL_SIZE", &c.Mongodb.MaxPoolSize},
+		{"MONGODB_MIN_POOL_SIZE", &c.Mongodb.MinPoolSize},
+		{"MONGODB_MAX_CONN_IDLE_TIME", &c.Mongodb.MaxConnIdleTime},
+		{"MONGODB_CONNECT_TIMEOUT", &c.Mongodb.ConnectTimeout},
+		{"MONGODB_SERVER_SELECTION_TIMEOUT", &c.Mongodb.ServerSelectionTimeout},
+		{"MONGODB_OPERATION_TIMEOUT", &c.Mongodb.OperationTimeout},
+		{"POSTGRES_URL", &c.Postgres.Url},
+		{"POSTGRES_MAX_OPEN_CONNS", &c.Postgres.MaxOpenConns},
+		{"POSTGRES_MAX_IDLE_CONNS", &c.Postgres.MaxIdleConns},
+		{"POSTGRES_CONN_MAX_LIFETIME", &c.Postgres.ConnMaxLifetime},
+		{"POSTGRES_OPERATION_TIMEOUT", &c.Postgres.OperationTimeout},
+		{"JWT_KEY", &c.Auth.JwtKey},
+		{"SESSION_KEY", &c.Auth.SessionKey},
+		{"PASSWORD_ALGORITHM", &c.Password.Algorithm},
+		{"BCRYPT_COST", &c.Password.BcryptCost},
+		{"ARGON2_MEMORY", &c.Password.Argon2Memory},
+		{"ARGON2_ITERATIONS", &c.Password.Argon2Iterations},
+		{"ARGON2_PARALLELISM", &c.Password.Argon2Parallelism},
+		{"SMTP_HOST", &c.Smtp.Host},
+		{"SMTP_PORT", &c.Smtp.Port},
+		{"SMTP_USER", &c.Smtp.User},
+		{"SMTP_PASSWORD", &c.Smtp.Password},
+		{"SMTP_FROM", &c.Smtp.From},
+		{"METRICS_TOKEN", &c.Metrics.Token},
+		{"TRACING_EXPORTER", &c.Tracing.Exporter},
+		{"TRACING_OTLP_ENDPOINT", &c.Tracing.OtlpEndpoint},
+		{"ADMIN_USER_IDS", &c.Admin.UserIds},
+		{"RATE_LIMIT_STORE", &c.RateLimit.Store},
+		{"RATE_LIMITS", &c.RateLimit.Limits},
+		{"CORS_ALLOWED_ORIGINS", &c.Cors.AllowedOrigins},
+		{"CORS_ALLOW_CREDENTIALS", &c.Cors.AllowCredentials},
+		{"CORS_MAX_AGE", &c.Cors.MaxAge},
+		{"HSTS_MAX_AGE", &c.Security.HstsMaxAge},
+		{"COOKIE_SAME_SITE", &c.Cookie.SameSite},
+		{"COOKIE_DOMAIN", &c.Cookie.Domain},
+	}
+}
+
+func readFile(path string) (map[string]string, error) {
+	values := make(map[string]string)
+	if len(path) == 0 {
+		return values, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &values)
+	if err != nil {
+		return nil, errors.New(fileEnv + " is not a JSON object of strings: " + err.Error())
+	}
+
+	return values, nil
+}
+
+func parse(value string, target interface{}) error {
+	switch target := target.(type) {
+	case *string:
+		*target = value
+	case *int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("is not a number")
+		}
+
+		*target = parsed
+	case *int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("is not a number")
+		}
+
+		*target = parsed
+	case *bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("is not a boolean, like true")
+		}
+
+		*target = parsed
+	case *time.Duration:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.New("is not a duration, like 10s")
+		}
+
+		*target = parsed
+	}
+
+	return nil
+}
+
+// IsAdmin reports whether the user is one of the ADMIN_USER_IDS.
+func (a *Admin) IsAdmin(userId string) bool {
+	for _, adminId := range a.ids() {
+		if adminId == userId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *Admin) ids() []string {
+	return splitList(a.UserIds)
+}
+
+// splitList splits a comma-separated value, without the empty entries.
+func splitList(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) != 0 {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// Proxies returns the ranges of the trusted proxies. A single address is a
+// range of its own.
+func (s *Server) Proxies() []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, entry := range splitList(s.TrustedProxies) {
+		proxy, err := parseProxy(entry)
+		if err == nil {
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	return proxies
+}
+
+func parseProxy(entry string) (*net.IPNet, error) {
+	invalid := errors.New(`"` + entry + `" is not an address or a CIDR range`)
+	if strings.Contains(entry, "/") {
+		_, proxy, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, invalid
+		}
+
+		return proxy, nil
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, invalid
+	}
+
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 8 * net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// Origins returns the allowed origins, which may contain "*".
+func (c *Cors) Origins() []string {
+	return splitList(c.AllowedOrigins)
+}
+
+// RouteLimits parses the limits, which are written as
+// "METHOD /pattern=requests/period".
+func (r *RateLimit) RouteLimits() ([]RouteLimit, error) {
+	var limits []RouteLimit
+	for _, entry := range splitList(r.Limits) {
+		limit, err := parseRouteLimit(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		limits = append(limits, *limit)
+	}
+
+	return limits, nil
+}
+
+func parseRouteLimit(entry string) (*RouteLimit, error) {
+	invalid := errors.New(`"` + entry + `" is not like "POST /register=5/1m"`)
+	route := strings.SplitN(entry, "=", 2)
+	if len(route) != 2 {
+		return nil, invalid
+	}
+
+	methodPattern := strings.Fields(route[0])
+	if len(methodPattern) != 2 || !strings.HasPrefix(methodPattern[1], "/") {
+		return nil, invalid
+	}
+
+	rate := strings.SplitN(strings.TrimSpace(route[1]), "/", 2)
+	if len(rate) != 2 {
+		return nil, invalid
+	}
+
+	requests, err := strconv.Atoi(rate[0])
+	if err != nil || requests <= 0 {
+		return nil, invalid
+	}
+
+	period, err := time.ParseDuration(rate[1])
+	if err != nil || period <= 0 {
+		return nil, invalid
+	}
+
+	return &RouteLimit{
+		Method:   strings.ToUpper(methodPattern[0]),
+		Pattern:  methodPattern[1],
+		Requests: requests,
+		Period:   period,
+	}, nil
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testKey = "a-key-which-is-long-enough-for-hs256"
+)
+
+// validValues is the smallest valid configuration, on top of the defaults.
+func validValues() map[string]string {
+	return map[string]string{
+		"STORAGE":     MemoryStorage,
+		"JWT_KEY":     testKey,
+		"SESSION_KEY": testKey,
+	}
+}
+
+func TestFromValues(t *testing.T) {
+	config, err := FromValues(map[string]string{
+		"STORAGE":                MemoryStorage,
+		"JWT_KEY":                testKey,
+		"SESSION_KEY":            testKey,
+		"SERVER_WRITE_TIMEOUT":   "30s",
+		"MAX_BODY_BYTES":         "2048",
+		"BCRYPT_COST":            "12",
+		"CORS_ALLOW_CREDENTIALS": "true",
+		"CORS_ALLOWED_ORIGINS":   "https://survey.example.com",
+		"UNKNOWN_SETTING":        "ignored",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Server.WriteTimeout != 30*time.Second || config.Server.MaxBodyBytes != 2048 ||
+		config.Password.BcryptCost != 12 || !config.Cors.AllowCredentials {
+		t.Errorf("expected the values to be parsed, got %+v", config)
+	}
+
+	if config.Server.Port != "3000" || config.Server.ReadTimeout != 10*time.Second {
+		t.Errorf("expected the defaults of the other settings, got %+v", config.Server)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]string
+		problem string
+	}{
+		{"short JWT key", map[string]string{"JWT_KEY": "short"}, "JWT_KEY must be at least 32 bytes long"},
+		{"short session key", map[string]string{"SESSION_KEY": "short"}, "SESSION_KEY must be at least 32 bytes long"},
+		{"unknown storage", map[string]string{"STORAGE": "redis"}, "STORAGE must be one of"},
+		{"log level", map[string]string{"LOG_LEVEL": "verbose"}, "LOG_LEVEL must be one of"},
+		{"malformed duration", map[string]string{"SERVER_READ_TIMEOUT": "10"}, "SERVER_READ_TIMEOUT is not a duration"},
+		{"zero duration", map[string]string{"SERVER_IDLE_TIMEOUT": "0s"}, "SERVER_IDLE_TIMEOUT must be positive"},
+		{"malformed number", map[string]string{"MAX_BODY_BYTES": "1MB"}, "MAX_BODY_BYTES is not a number"},
+		{"malformed boolean", map[string]string{"CORS_ALLOW_CREDENTIALS": "yes please"}, "CORS_ALLOW_CREDENTIALS is not a boolean"},
+		{"port", map[string]string{"PORT": "70000"}, "PORT must be a port number"},
+		{"half of TLS", map[string]string{"TLS_CERT_FILE": "cert.pem"}, "TLS_CERT_FILE and TLS_KEY_FILE must be set together"},
+		{"bcrypt cost", map[string]string{"BCRYPT_COST": "2"}, "BCRYPT_COST must be between"},
+		{"mongodb URI", map[string]string{"STORAGE": MongodbStorage, "MONGODB_URI": "http://localhost"}, "MONGODB_URI must be a mongodb://"},
+		{"mongodb credentials", map[string]string{"STORAGE": MongodbStorage}, "MONGODB_USER is not set"},
+		{"mongodb database", map[string]string{"STORAGE": MongodbStorage, "MONGODB_URI": "mongodb://localhost", "MONGODB_DATABASE": "a.b"}, "MONGODB_DATABASE must be a valid database name"},
+		{"mongodb pool", map[string]string{"STORAGE": MongodbStorage, "MONGODB_URI": "mongodb://localhost", "MONGODB_MIN_POOL_SIZE": "10", "MONGODB_MAX_POOL_SIZE": "5"}, "MONGODB_MIN_POOL_SIZE must not be greater"},
+		{"postgres URL", map[string]string{"STORAGE": PostgresStorage, "POSTGRES_URL": "mysql://localhost/survey"}, "POSTGRES_URL must be a postgres:// URL"},
+		{"tracing exporter", map[string]string{"TRACING_EXPORTER": "jaeger"}, "TRACING_EXPORTER must be one of"},
+		{"otlp endpoint", map[string]string{"TRACING_EXPORTER": OtlpTracing, "TRACING_OTLP_ENDPOINT": "localhost:55681"}, "TRACING_OTLP_ENDPOINT must be an http://"},
+		{"admin ids", map[string]string{"ADMIN_USER_IDS": "alice"}, "ADMIN_USER_IDS must be user ids"},
+		{"rate limit store", map[string]string{"RATE_LIMIT_STORE": MongodbStorage}, "RATE_LIMIT_STORE can only be mongodb"},
+		{"rate limits", map[string]string{"RATE_LIMITS": "POST /register=5 per minute"}, "RATE_LIMITS"},
+		{"CORS origin", map[string]string{"CORS_ALLOWED_ORIGINS": "https://survey.example.com/"}, "CORS_ALLOWED_ORIGINS must be"},
+		{"CORS credentials", map[string]string{"CORS_ALLOWED_ORIGINS": "*", "CORS_ALLOW_CREDENTIALS": "true"}, "CORS_ALLOW_CREDENTIALS is not allowed"},
+		{"argon2 memory", map[string]string{"ARGON2_MEMORY": "4294967360"}, "ARGON2_MEMORY must be at most"},
+		{"SameSite", map[string]string{"COOKIE_SAME_SITE": "sometimes"}, "COOKIE_SAME_SITE must be one of"},
+		{"trusted proxies", map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8, proxy.internal"}, `TRUSTED_PROXIES "proxy.internal" is not`},
+		{"trusted proxy range", map[string]string{"TRUSTED_PROXIES": "10.0.0.0/33"}, `TRUSTED_PROXIES "10.0.0.0/33" is not`},
+	}
+	for _, test := range tests {
+		values := validValues()
+		for key, value := range test.values {
+			values[key] = value
+		}
+
+		_, err := FromValues(values)
+		configError, ok := err.(*Error)
+		if !ok {
+			t.Errorf("%s: expected a configuration error, got %v", test.name, err)
+			continue
+		}
+
+		if !strings.Contains(configError.Error(), test.problem) {
+			t.Errorf("%s: expected %q, got %v", test.name, test.problem, configError.Problems)
+		}
+	}
+}
+
+func TestValidationReportsEveryProblem(t *testing.T) {
+	_, err := FromValues(map[string]string{
+		"STORAGE":   MemoryStorage,
+		"LOG_LEVEL": "verbose",
+		"PORT":      "port",
+	})
+	configError, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected a configuration error, got %v", err)
+	}
+
+	if len(configError.Problems) != 4 {
+		t.Fatalf("expected both keys, the log level and the port, got %v", configError.Problems)
+	}
+
+	if !strings.HasPrefix(configError.Error(), "Invalid configuration:\n  - JWT_KEY") {
+		t.Errorf("expected a report of every problem, got %s", configError.Error())
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file, err := ioutil.TempFile("", "config-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`{"STORAGE": "memory", "JWT_KEY": "` + testKey + `", "SESSION_KEY": "` + testKey + `", "PORT": "4000", "HOST": "0.0.0.0", "CORS_ALLOWED_ORIGINS": "https://survey.example.com"}`)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The environment overrides the file and the defaults, even with an
+	// empty value.
+	env := map[string]string{
+		fileEnv:                file.Name(),
+		"PORT":                 "5000",
+		"RATE_LIMITS":          "",
+		"CORS_ALLOWED_ORIGINS": "",
+	}
+	for key, value := range env {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+
+	config, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Server.Port != "5000" || config.Server.Host != "0.0.0.0" || config.Storage != MemoryStorage {
+		t.Errorf("expected the environment to override the file, got %+v", config.Server)
+	}
+
+	if len(config.RateLimit.Limits) != 0 || len(config.Cors.AllowedOrigins) != 0 {
+		t.Errorf("expected empty variables to clear the values, got %+v and %+v", config.RateLimit, config.Cors)
+	}
+}
+
+func TestLoadRejectsMalformedFiles(t *testing.T) {
+	file, err := ioutil.TempFile("", "config-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`{"PORT": 4000}`)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv(fileEnv, file.Name())
+	defer os.Unsetenv(fileEnv)
+	_, err = Load()
+	if err == nil || !strings.Contains(err.Error(), "is not a JSON object of strings") {
+		t.Errorf("expected the file to be rejected, got %v", err)
+	}
+
+	os.Setenv(fileEnv, file.Name()+".missing")
+	_, err = Load()
+	if !os.IsNotExist(err) {
+		t.Errorf("expected a missing file to be reported, got %v", err)
+	}
+}
+
+func TestRouteLimits(t *testing.T) {
+	rateLimit := &RateLimit{Limits: " post /register=5/1m,, PUT /poll/vote=60/1h "}
+	limits, err := rateLimit.RouteLimits()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []RouteLimit{
+		{Method: "POST", Pattern: "/register", Requests: 5, Period: time.Minute},
+		{Method: "PUT", Pattern: "/poll/vote", Requests: 60, Period: time.Hour},
+	}
+	if len(limits) != len(expected) || limits[0] != expected[0] || limits[1] != expected[1] {
+		t.Errorf("expected %+v, got %+v", expected, limits)
+	}
+
+	for _, invalid := range []string{"POST=5/1m", "POST /register=0/1m", "POST /register=5/0s", "POST register=5/1m"} {
+		rateLimit.Limits = invalid
+		_, err = rateLimit.RouteLimits()
+		if err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestProxies(t *testing.T) {
+	server := &Server{TrustedProxies: " 10.0.0.0/8,, 192.0.2.1, 2001:db8::1 "}
+	proxies := server.Proxies()
+	expected := []string{"10.0.0.0/8", "192.0.2.1/32", "2001:db8::1/128"}
+	if len(proxies) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, proxies)
+	}
+
+	for i, proxy := range proxies {
+		if proxy.String() != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], proxy)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// HS256 keys and securecookie hash keys should be at least as long as
+	// the output of SHA-256.
+	minKeyLength = 32
+	// 1 GiB in KiB. Every password hash takes that much memory, so larger
+	// values let a few concurrent logins exhaust the memory.
+	maxArgon2Memory = 1024 * 1024
+)
+
+var (
+	objectIdRegex = regexp.MustCompile(`^[0-9a-f]{24}$`)
+)
+
+func (c *Config) validate() []string {
+	var problems []string
+	problem := func(message string) {
+		problems = append(problems, message)
+	}
+
+	if len(c.Auth.JwtKey) < minKeyLength {
+		problem("JWT_KEY must be at least " + strconv.Itoa(minKeyLength) + " bytes long")
+	}
+
+	if len(c.Auth.SessionKey) < minKeyLength {
+		problem("SESSION_KEY must be at least " + strconv.Itoa(minKeyLength) + " bytes long")
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		problem("LOG_LEVEL must be one of debug, info, warn, error")
+	}
+
+	switch c.Storage {
+	case MongodbStorage:
+		problems = append(problems, c.Mongodb.validate()...)
+	case PostgresStorage:
+		problems = append(problems, c.Postgres.validate()...)
+	case MemoryStorage:
+	default:
+		problem("STORAGE must be one of " + MongodbStorage + ", " + PostgresStorage + ", " + MemoryStorage)
+	}
+
+	switch c.Tracing.Exporter {
+	case NoTracing, StdoutTracing:
+	case OtlpTracing:
+		uri, err := url.Parse(c.Tracing.OtlpEndpoint)
+		if err != nil || (uri.Scheme != "http" && uri.Scheme != "https") || len(uri.Host) == 0 {
+			problem("TRACING_OTLP_ENDPOINT must be an http:// or https:// URL")
+		}
+	default:
+		problem("TRACING_EXPORTER must be one of " + NoTracing + ", " + StdoutTracing + ", " + OtlpTracing)
+	}
+
+	switch c.RateLimit.Store {
+	case MemoryStorage:
+	case MongodbStorage:
+		if c.Storage != MongodbStorage {
+			problem("RATE_LIMIT_STORE can only be " + MongodbStorage + " with the " + MongodbStorage + " STORAGE")
+		}
+	default:
+		problem("RATE_LIMIT_STORE must be one of " + MemoryStorage + ", " + MongodbStorage)
+	}
+
+	_, err := c.RateLimit.RouteLimits()
+	if err != nil {
+		problem("RATE_LIMITS " + err.Error())
+	}
+
+	problems = append(problems, c.Cors.validate()...)
+	if c.Security.HstsMaxAge < 0 {
+		problem("HSTS_MAX_AGE must not be negative")
+	}
+
+	switch c.Cookie.SameSite {
+	case "strict", "lax", "none":
+	default:
+		problem("COOKIE_SAME_SITE must be one of strict, lax, none")
+	}
+
+	if strings.ContainsAny(c.Cookie.Domain, " ;,:/") {
+		problem("COOKIE_DOMAIN must be a domain name")
+	}
+
+	for _, id := range c.Admin.ids() {
+		if !objectIdRegex.MatchString(id) {
+			problem("ADMIN_USER_IDS must be user ids, separated by commas")
+			break
+		}
+	}
+
+	ports := []struct {
+		key  string
+		port string
+	}{
+		{"PORT", c.Server.Port},
+		{"SMTP_PORT", c.Smtp.Port},
+	}
+	for _, port := range ports {
+		number, err := strconv.Atoi(port.port)
+		if err != nil || number <= 0 || number > 65535 {
+			problem(port.key + " must be a port number")
+		}
+	}
+
+	durations := []struct {
+		key      string
+		duration time.Duration
+	}{
+		{"SERVER_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"SERVER_READ_HEADER_TIMEOUT", c.Server.ReadHeaderTimeout},
+		{"SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+	}
+	for _, duration := range durations {
+		if duration.duration <= 0 {
+			problem(duration.key + " must be positive")
+		}
+	}
+
+	if (len(c.Server.TLSCertFile) == 0) != (len(c.Server.TLSKeyFile) == 0) {
+		problem("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if c.Server.MaxBodyBytes <= 0 {
+		problem("MAX_BODY_BYTES must be positive")
+	}
+
+	for _, entry := range splitList(c.Server.TrustedProxies) {
+		_, err := parseProxy(entry)
+		if err != nil {
+			problem("TRUSTED_PROXIES " + err.Error())
+		}
+	}
+
+	if c.Password.Algorithm != "bcrypt" && c.Password.Algorithm != "argon2id" {
+		problem("PASSWORD_ALGORITHM must be one of bcrypt, argon2id")
+	}
+
+	if c.Password.BcryptCost < bcrypt.MinCost || c.Password.BcryptCost > bcrypt.MaxCost {
+		problem("BCRYPT_COST must be between " + strconv.Itoa(bcrypt.MinCost) + " and " + strconv.Itoa(bcrypt.MaxCost))
+	}
+
+	if c.Password.Argon2Memory <= 0 || c.Password.Argon2Iterations <= 0 {
+		problem("ARGON2_MEMORY and ARGON2_ITERATIONS must be positive")
+	}
+
+	if c.Password.Argon2Memory > maxArgon2Memory {
+		problem("ARGON2_MEMORY must be at most " + strconv.Itoa(maxArgon2Memory) + " KiB")
+	}
+
+	if c.Password.Argon2Parallelism <= 0 || c.Password.Argon2Parallelism > 255 {
+		problem("ARGON2_PARALLELISM must be between 1 and 255")
+	}
+
+	return problems
+}
+
+func (m *Mongodb) validate() []string {
+	var problems []string
+	problem := func(message string) {
+		problems = append(problems, message)
+	}
+
+	// The URI is only checked for its format. Resolving a mongodb+srv://
+	// URI needs a DNS lookup, which is left to the connection.
+	if len(m.Uri) != 0 {
+		uri, err := url.Parse(m.Uri)
+		if err != nil || (uri.Scheme != "mongodb" && uri.Scheme != "mongodb+srv") || len(uri.Host) == 0 {
+			problem("MONGODB_URI must be a mongodb:// or mongodb+srv:// URI")
+		}
+	} else {
+		if len(m.User) == 0 {
+			problem("MONGODB_USER is not set")
+		}
+
+		if len(m.Password) == 0 {
+			problem("MONGODB_PASSWORD is not set")
+		}
+
+		port, err := strconv.Atoi(m.Port)
+		if err != nil || port <= 0 || port > 65535 {
+			problem("MONGODB_PORT must be a port number")
+		}
+	}
+
+	if len(m.Database) == 0 || strings.ContainsAny(m.Database, `/\. "$`) {
+		problem("MONGODB_DATABASE must be a valid database name")
+	}
+
+	if m.MaxPoolSize < 0 || m.MinPoolSize < 0 {
+		problem("MONGODB_MAX_POOL_SIZE and MONGODB_MIN_POOL_SIZE must not be negative")
+	}
+
+	if m.MaxPoolSize != 0 && m.MinPoolSize > m.MaxPoolSize {
+		problem("MONGODB_MIN_POOL_SIZE must not be greater than MONGODB_MAX_POOL_SIZE")
+	}
+
+	if m.MaxConnIdleTime < 0 || m.ConnectTimeout < 0 || m.ServerSelectionTimeout < 0 {
+		problem("MONGODB timeouts must not be negative")
+	}
+
+	if m.OperationTimeout <= 0 {
+		problem("MONGODB_OPERATION_TIMEOUT must be positive")
+	}
+
+	return problems
+}
+
+func (c *Cors) validate() []string {
+	var problems []string
+	problem := func(message string) {
+		problems = append(problems, message)
+	}
+
+	for _, origin := range c.Origins() {
+		if origin == "*" {
+			if c.AllowCredentials {
+				problem("CORS_ALLOW_CREDENTIALS is not allowed with any origin")
+			}
+
+			continue
+		}
+
+		// Browsers send the origin without a path, which is compared as is.
+		uri, err := url.Parse(origin)
+		if err != nil || (uri.Scheme != "http" && uri.Scheme != "https") || len(uri.Host) == 0 || origin != uri.Scheme+"://"+uri.Host {
+			problem("CORS_ALLOWED_ORIGINS must be * or origins, like https://survey.example.com")
+			break
+		}
+	}
+
+	if c.MaxAge < 0 {
+		problem("CORS_MAX_AGE must not be negative")
+	}
+
+	return problems
+}
+
+func (p *Postgres) validate() []string {
+	var problems []string
+	problem := func(message string) {
+		problems = append(problems, message)
+	}
+
+	uri, err := url.Parse(p.Url)
+	if err != nil || (uri.Scheme != "postgres" && uri.Scheme != "postgresql") || len(uri.Host) == 0 {
+		problem("POSTGRES_URL must be a postgres:// URL")
+	}
+
+	if p.MaxOpenConns < 0 || p.MaxIdleConns < 0 {
+		problem("POSTGRES_MAX_OPEN_CONNS and POSTGRES_MAX_IDLE_CONNS must not be negative")
+	}
+
+	if p.ConnMaxLifetime < 0 {
+		problem("POSTGRES_CONN_MAX_LIFETIME must not be negative")
+	}
+
+	if p.OperationTimeout <= 0 {
+		problem("POSTGRES_OPERATION_TIMEOUT must be positive")
+	}
+
+	return problems
+}
@@ -1,115 +1,188 @@
-//+build wireinject
+//go:build wireinject
+// +build wireinject
 
 package di
 
 import (
-	"context"
-	"errors"
-	"os"
+	"database/sql"
+	audithandler "survey-api/pkg/audit/handler"
+	auditrepo "survey-api/pkg/audit/repo"
 	"survey-api/pkg/auth/cookie"
 	"survey-api/pkg/auth/handler"
+	"survey-api/pkg/auth/password"
 	authrepo "survey-api/pkg/auth/repo"
 	"survey-api/pkg/auth/token"
+	"survey-api/pkg/config"
+	healthhandler "survey-api/pkg/health/handler"
 	"survey-api/pkg/logger"
+	"survey-api/pkg/metrics"
+	"survey-api/pkg/notification"
 	pollhandler "survey-api/pkg/poll/handler"
 	pollrepo "survey-api/pkg/poll/repo"
+	"survey-api/pkg/ratelimit"
+	"survey-api/pkg/storage"
+	"survey-api/pkg/tracing"
 	userrepo "survey-api/pkg/user/repo"
-	"time"
+	"sync"
 
 	"github.com/google/wire"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Dependencies struct {
+	Config        *config.Config
+	MongoClient   *mongo.Client
+	PostgresDB    *sql.DB
+	StorageGate   *storage.Gate
 	Logger        *logger.Service
+	Metrics       *metrics.Service
+	Tracing       *tracing.Service
 	AuthHandler   *handler.Service
 	TokenService  *token.Service
 	CookieService *cookie.Service
-	AuthRepo      *authrepo.Service
-	UserRepo      *userrepo.Service
-	PollRepo      *pollrepo.Service
+	AuthRepo      authrepo.Repository
+	UserRepo      userrepo.Repository
+	PollRepo      pollrepo.Repository
 	PollHandler   *pollhandler.Service
+	AuditRepo     auditrepo.Repository
+	AuditHandler  *audithandler.Service
+	HealthHandler *healthhandler.Service
+	RateLimiter   *ratelimit.Service
 }
 
-var dependencies *Dependencies
+// Base holds the dependencies, which need no storage. They are created
+// once, before the others, so that a failure of the storage can still be
+// logged, and so that the metrics are only registered once.
+type Base struct {
+	Config  *config.Config
+	Logger  *logger.Service
+	Metrics *metrics.Service
+	Tracing *tracing.Service
+}
 
-func init() {
-	deps, err := create()
-	if err != nil {
-		panic(err)
-	}
+var (
+	baseDependencies  *Base
+	dependencies      *Dependencies
+	dependenciesMutex sync.Mutex
+)
 
-	dependencies = deps
+// Container creates the dependencies on the first call, so that importing
+// a package does not connect to the database. Failures are not cached, the
+// next call tries again.
+func Container() (*Dependencies, error) {
+	return container(config.Load)
 }
 
-func Container() *Dependencies {
-	return dependencies
+// ContainerWith is Container with a configuration, which the caller has
+// already loaded, so that both use the same one.
+func ContainerWith(c *config.Config) (*Dependencies, error) {
+	return container(func() (*config.Config, error) {
+		return c, nil
+	})
 }
 
-func create() (*Dependencies, error) {
-	panic(wire.Build(
-		wire.Struct(new(logger.Service), "*"),
-		wire.Struct(new(token.Service), "*"),
-		wire.Struct(new(cookie.Service), "*"),
-		createMongodbClient,
-		userrepo.New,
-		authrepo.New,
-		handler.New,
-		pollrepo.New,
-		pollhandler.New,
-		packageDependencies,
-	))
+// Logger returns the configured logger, or a default one while the
+// configuration cannot be loaded.
+func Logger() *logger.Service {
+	dependenciesMutex.Lock()
+	defer dependenciesMutex.Unlock()
+	if baseDependencies == nil {
+		return &logger.Service{}
+	}
+
+	return baseDependencies.Logger
 }
 
-func createMongodbClient() (*mongo.Client, error) {
-	host := os.Getenv("MONGODB_HOST")
-	if len(host) == 0 {
-		host = "localhost"
+// The configuration is only loaded when the dependencies are created. The
+// storage is opened without waiting for the server, so the mutex is never
+// held while waiting for it. StorageGate waits for it, with retries, on
+// first use.
+func container(load func() (*config.Config, error)) (*Dependencies, error) {
+	dependenciesMutex.Lock()
+	defer dependenciesMutex.Unlock()
+	if dependencies != nil {
+		return dependencies, nil
 	}
 
-	port := os.Getenv("MONGODB_PORT")
-	if len(port) == 0 {
-		port = "27017"
-	}
+	if baseDependencies == nil {
+		c, err := load()
+		if err != nil {
+			return nil, err
+		}
 
-	user := os.Getenv("MONGODB_USER")
-	if len(user) == 0 {
-		return nil, errors.New("MONGODB_USER is not set")
-	}
+		created, err := createBase(c)
+		if err != nil {
+			return nil, err
+		}
 
-	password := os.Getenv("MONGODB_PASSWORD")
-	if len(password) == 0 {
-		return nil, errors.New("MONGODB_PASSWORD is not set")
+		baseDependencies = created
 	}
 
-	url := "mongodb://" + host + ":" + port
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	clientOptions := options.Client().ApplyURI(url).SetAuth(options.Credential{
-		Username: user,
-		Password: password,
-	})
-	client, err := mongo.Connect(ctx, clientOptions)
-	defer cancel()
+	deps, err := create(baseDependencies)
 	if err != nil {
 		return nil, err
 	}
 
-	return client, nil
+	dependencies = deps
+	return dependencies, nil
+}
+
+func createBase(c *config.Config) (*Base, error) {
+	panic(wire.Build(
+		logger.New,
+		metrics.New,
+		tracing.New,
+		wire.Struct(new(Base), "*"),
+	))
+}
+
+func create(base *Base) (*Dependencies, error) {
+	panic(wire.Build(
+		wire.FieldsOf(new(*Base), "Config", "Logger", "Metrics", "Tracing"),
+		token.New,
+		cookie.New,
+		notification.New,
+		password.New,
+		createStorage,
+		wire.FieldsOf(new(*Storage), "MongoClient", "PostgresDB", "Gate", "Migrations", "UserRepo", "AuthRepo", "PollRepo", "AuditRepo"),
+		handler.New,
+		pollhandler.New,
+		audithandler.New,
+		healthhandler.New,
+		createRateLimitStore,
+		ratelimit.New,
+		packageDependencies,
+	))
 }
 
 func packageDependencies(
+	config *config.Config,
+	mongoClient *mongo.Client,
+	postgresDB *sql.DB,
+	storageGate *storage.Gate,
 	logger *logger.Service,
+	metrics *metrics.Service,
+	tracing *tracing.Service,
 	authHandler *handler.Service,
 	tokenService *token.Service,
 	cookieService *cookie.Service,
-	authRepo *authrepo.Service,
-	userRepo *userrepo.Service,
-	pollRepo *pollrepo.Service,
+	authRepo authrepo.Repository,
+	userRepo userrepo.Repository,
+	pollRepo pollrepo.Repository,
 	pollHandler *pollhandler.Service,
+	auditRepo auditrepo.Repository,
+	auditHandler *audithandler.Service,
+	healthHandler *healthhandler.Service,
+	rateLimiter *ratelimit.Service,
 ) *Dependencies {
 	return &Dependencies{
+		Config:        config,
+		MongoClient:   mongoClient,
+		PostgresDB:    postgresDB,
+		StorageGate:   storageGate,
 		Logger:        logger,
+		Metrics:       metrics,
+		Tracing:       tracing,
 		AuthHandler:   authHandler,
 		TokenService:  tokenService,
 		CookieService: cookieService,
@@ -117,5 +190,9 @@ func packageDependencies(
 		UserRepo:      userRepo,
 		PollRepo:      pollRepo,
 		PollHandler:   pollHandler,
+		AuditRepo:     auditRepo,
+		AuditHandler:  auditHandler,
+		HealthHandler: healthHandler,
+		RateLimiter:   rateLimiter,
 	}
 }
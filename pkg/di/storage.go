@@ -0,0 +1,131 @@
+package di
+
+import (
+	"context"
+	"database/sql"
+	auditrepo "survey-api/pkg/audit/repo"
+	authrepo "survey-api/pkg/auth/repo"
+	"survey-api/pkg/config"
+	"survey-api/pkg/metrics"
+	"survey-api/pkg/migration"
+	"survey-api/pkg/mongodb"
+	pollrepo "survey-api/pkg/poll/repo"
+	"survey-api/pkg/postgres"
+	"survey-api/pkg/ratelimit"
+	"survey-api/pkg/storage"
+	"survey-api/pkg/tracing"
+	userrepo "survey-api/pkg/user/repo"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Storage holds the repositories of the configured STORAGE. The client and
+// the database are nil, unless the storage is MongoDB and PostgreSQL
+// respectively. The gate waits for the server on first use.
+type Storage struct {
+	MongoClient *mongo.Client
+	PostgresDB  *sql.DB
+	Gate        *storage.Gate
+	Migrations  *migration.Service
+	UserRepo    userrepo.Repository
+	AuthRepo    authrepo.Repository
+	PollRepo    pollrepo.Repository
+	AuditRepo   auditrepo.Repository
+}
+
+func createStorage(c *config.Config, metrics *metrics.Service, tracingService *tracing.Service) (*Storage, error) {
+	opened, err := openStorage(c)
+	if err != nil {
+		return nil, err
+	}
+
+	opened.UserRepo = userrepo.NewInstrumented(opened.UserRepo, metrics, tracingService, c.Storage)
+	opened.AuthRepo = authrepo.NewInstrumented(opened.AuthRepo, metrics, tracingService, c.Storage)
+	opened.PollRepo = pollrepo.NewInstrumented(opened.PollRepo, metrics, tracingService, c.Storage)
+	opened.AuditRepo = auditrepo.NewInstrumented(opened.AuditRepo, metrics, tracingService, c.Storage)
+	return opened, nil
+}
+
+// The storage is opened without waiting for the server, so that a server,
+// which is down, fails only the requests using it, instead of the creation
+// of every dependency. Its gate waits for the server with retries, and for
+// the migrations to be applied.
+func openStorage(c *config.Config) (*Storage, error) {
+	switch c.Storage {
+	case config.MemoryStorage:
+		return &Storage{
+			Gate:      storage.NewGate(nil),
+			UserRepo:  userrepo.NewMemory(),
+			AuthRepo:  authrepo.NewMemory(),
+			PollRepo:  pollrepo.NewMemory(),
+			AuditRepo: auditrepo.NewMemory(),
+		}, nil
+	case config.PostgresStorage:
+		db, err := postgres.OpenDB(c)
+		if err != nil {
+			return nil, err
+		}
+
+		migrations := migration.NewPostgres(db)
+		return &Storage{
+			PostgresDB: db,
+			Gate: storage.NewGate(func(ctx context.Context) error {
+				err := postgres.WaitReady(ctx, db)
+				if err != nil {
+					return err
+				}
+
+				return migrationsApplied(storage.FromPostgres(migrations.Check(ctx), nil))
+			}),
+			Migrations: migrations,
+			UserRepo:   userrepo.NewPostgres(db, c),
+			AuthRepo:   authrepo.NewPostgres(db, c),
+			PollRepo:   pollrepo.NewPostgres(db, c),
+			AuditRepo:  auditrepo.NewPostgres(db, c),
+		}, nil
+	}
+
+	client, err := mongodb.OpenClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	db := mongodb.NewDatabase(client, c)
+	migrations := migration.NewMongodb(db)
+	return &Storage{
+		MongoClient: client,
+		Gate: storage.NewGate(func(ctx context.Context) error {
+			err := mongodb.WaitReady(ctx, client, c)
+			if err != nil {
+				return err
+			}
+
+			return migrationsApplied(storage.FromMongo(migrations.Check(ctx), nil))
+		}),
+		Migrations: migrations,
+		UserRepo:   userrepo.New(db, c),
+		AuthRepo:   authrepo.New(db, c),
+		PollRepo:   pollrepo.New(db, c),
+		AuditRepo:  auditrepo.New(db, c),
+	}, nil
+}
+
+// Without the migrations, the unique indexes of the user names and emails
+// may be missing, so the storage is not ready while any is pending.
+func migrationsApplied(err error) error {
+	if err == migration.ErrPending {
+		return &storage.UnavailableError{Err: err}
+	}
+
+	return err
+}
+
+// The mongodb store shares the client of the mongodb STORAGE, which the
+// configuration requires for it.
+func createRateLimitStore(c *config.Config, opened *Storage) ratelimit.Store {
+	if c.RateLimit.Store == config.MongodbStorage {
+		return ratelimit.NewMongo(mongodb.NewDatabase(opened.MongoClient, c), c)
+	}
+
+	return ratelimit.NewMemory()
+}
@@ -1,130 +1,214 @@
 // Code generated by Wire. DO NOT EDIT.
 
 //go:generate wire
-//+build !wireinject
+//go:build !wireinject
+// +build !wireinject
 
 package di
 
 import (
-	"context"
-	"errors"
+	"database/sql"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-	"os"
+	"survey-api/pkg/audit/handler"
+	repo4 "survey-api/pkg/audit/repo"
 	"survey-api/pkg/auth/cookie"
-	"survey-api/pkg/auth/handler"
-	repo2 "survey-api/pkg/auth/repo"
+	handler2 "survey-api/pkg/auth/handler"
+	"survey-api/pkg/auth/password"
+	"survey-api/pkg/auth/repo"
 	"survey-api/pkg/auth/token"
+	"survey-api/pkg/config"
+	handler4 "survey-api/pkg/health/handler"
 	"survey-api/pkg/logger"
-	handler2 "survey-api/pkg/poll/handler"
+	"survey-api/pkg/metrics"
+	"survey-api/pkg/notification"
+	handler3 "survey-api/pkg/poll/handler"
 	repo3 "survey-api/pkg/poll/repo"
-	"survey-api/pkg/user/repo"
-	"time"
+	"survey-api/pkg/ratelimit"
+	"survey-api/pkg/storage"
+	"survey-api/pkg/tracing"
+	repo2 "survey-api/pkg/user/repo"
+	"sync"
 )
 
 // Injectors from di.go:
 
-func create() (*Dependencies, error) {
-	service := &logger.Service{}
-	client, err := createMongodbClient()
+func createBase(c *config.Config) (*Base, error) {
+	service, err := logger.New(c)
 	if err != nil {
 		return nil, err
 	}
-	repoService, err := repo.New(client)
+	metricsService := metrics.New()
+	tracingService, err := tracing.New(c)
 	if err != nil {
 		return nil, err
 	}
-	service2, err := repo2.New(client)
+	base := &Base{
+		Config:  c,
+		Logger:  service,
+		Metrics: metricsService,
+		Tracing: tracingService,
+	}
+	return base, nil
+}
+
+func create(base *Base) (*Dependencies, error) {
+	configConfig := base.Config
+	service := base.Metrics
+	tracingService := base.Tracing
+	storage, err := createStorage(configConfig, service, tracingService)
 	if err != nil {
 		return nil, err
 	}
-	tokenService := &token.Service{}
-	cookieService := &cookie.Service{}
-	handlerService := handler.New(repoService, service2, tokenService, cookieService)
-	service3, err := repo3.New(client)
+	client := storage.MongoClient
+	db := storage.PostgresDB
+	gate := storage.Gate
+	loggerService := base.Logger
+	repository := storage.AuditRepo
+	handlerService := handler.New(loggerService, repository)
+	repoRepository := storage.UserRepo
+	repository2 := storage.AuthRepo
+	tokenService := token.New(configConfig)
+	cookieService := cookie.New(configConfig)
+	notificationService := notification.New(loggerService, configConfig)
+	passwordService := password.New(configConfig)
+	service2 := handler2.New(loggerService, service, tracingService, handlerService, repoRepository, repository2, tokenService, cookieService, notificationService, passwordService)
+	repository3 := storage.PollRepo
+	service3 := handler3.New(repository3, service, tracingService, handlerService)
+	migrationService := storage.Migrations
+	service4 := handler4.New(loggerService, client, db, migrationService, configConfig)
+	store := createRateLimitStore(configConfig, storage)
+	ratelimitService, err := ratelimit.New(configConfig, store)
 	if err != nil {
 		return nil, err
 	}
-	service4 := handler2.New(service3)
-	diDependencies := packageDependencies(service, handlerService, tokenService, cookieService, service2, repoService, service3, service4)
+	diDependencies := packageDependencies(configConfig, client, db, gate, loggerService, service, tracingService, service2, tokenService, cookieService, repository2, repoRepository, repository3, service3, repository, handlerService, service4, ratelimitService)
 	return diDependencies, nil
 }
 
 // di.go:
 
 type Dependencies struct {
+	Config        *config.Config
+	MongoClient   *mongo.Client
+	PostgresDB    *sql.DB
+	StorageGate   *storage.Gate
 	Logger        *logger.Service
-	AuthHandler   *handler.Service
+	Metrics       *metrics.Service
+	Tracing       *tracing.Service
+	AuthHandler   *handler2.Service
 	TokenService  *token.Service
 	CookieService *cookie.Service
-	AuthRepo      *repo2.Service
-	UserRepo      *repo.Service
-	PollRepo      *repo3.Service
-	PollHandler   *handler2.Service
+	AuthRepo      repo.Repository
+	UserRepo      repo2.Repository
+	PollRepo      repo3.Repository
+	PollHandler   *handler3.Service
+	AuditRepo     repo4.Repository
+	AuditHandler  *handler.Service
+	HealthHandler *handler4.Service
+	RateLimiter   *ratelimit.Service
 }
 
-var dependencies *Dependencies
+// Base holds the dependencies, which need no storage. They are created
+// once, before the others, so that a failure of the storage can still be
+// logged, and so that the metrics are only registered once.
+type Base struct {
+	Config  *config.Config
+	Logger  *logger.Service
+	Metrics *metrics.Service
+	Tracing *tracing.Service
+}
 
-func init() {
-	deps, err := create()
-	if err != nil {
-		panic(err)
-	}
+var (
+	baseDependencies  *Base
+	dependencies      *Dependencies
+	dependenciesMutex sync.Mutex
+)
 
-	dependencies = deps
+// Container creates the dependencies on the first call, so that importing
+// a package does not connect to the database. Failures are not cached, the
+// next call tries again.
+func Container() (*Dependencies, error) {
+	return container(config.Load)
 }
 
-func Container() *Dependencies {
-	return dependencies
+// ContainerWith is Container with a configuration, which the caller has
+// already loaded, so that both use the same one.
+func ContainerWith(c *config.Config) (*Dependencies, error) {
+	return container(func() (*config.Config, error) {
+		return c, nil
+	})
 }
 
-func createMongodbClient() (*mongo.Client, error) {
-	host := os.Getenv("MONGODB_HOST")
-	if len(host) == 0 {
-		host = "localhost"
+// Logger returns the configured logger, or a default one while the
+// configuration cannot be loaded.
+func Logger() *logger.Service {
+	dependenciesMutex.Lock()
+	defer dependenciesMutex.Unlock()
+	if baseDependencies == nil {
+		return &logger.Service{}
 	}
 
-	port := os.Getenv("MONGODB_PORT")
-	if len(port) == 0 {
-		port = "27017"
-	}
+	return baseDependencies.Logger
+}
 
-	user := os.Getenv("MONGODB_USER")
-	if len(user) == 0 {
-		return nil, errors.New("MONGODB_USER is not set")
+// The configuration is only loaded when the dependencies are created. The
+// storage is opened without waiting for the server, so the mutex is never
+// held while waiting for it. StorageGate waits for it, with retries, on
+// first use.
+func container(load func() (*config.Config, error)) (*Dependencies, error) {
+	dependenciesMutex.Lock()
+	defer dependenciesMutex.Unlock()
+	if dependencies != nil {
+		return dependencies, nil
 	}
 
-	password := os.Getenv("MONGODB_PASSWORD")
-	if len(password) == 0 {
-		return nil, errors.New("MONGODB_PASSWORD is not set")
+	if baseDependencies == nil {
+		c, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		created, err := createBase(c)
+		if err != nil {
+			return nil, err
+		}
+
+		baseDependencies = created
 	}
 
-	url := "mongodb://" + host + ":" + port
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	clientOptions := options.Client().ApplyURI(url).SetAuth(options.Credential{
-		Username: user,
-		Password: password,
-	})
-	client, err := mongo.Connect(ctx, clientOptions)
-	defer cancel()
+	deps, err := create(baseDependencies)
 	if err != nil {
 		return nil, err
 	}
 
-	return client, nil
+	dependencies = deps
+	return dependencies, nil
 }
 
-func packageDependencies(logger2 *logger.Service,
-	authHandler *handler.Service,
+func packageDependencies(config2 *config.Config,
+	mongoClient *mongo.Client,
+	postgresDB *sql.DB,
+	storageGate *storage.Gate, logger2 *logger.Service, metrics2 *metrics.Service, tracing2 *tracing.Service,
+	authHandler *handler2.Service,
 	tokenService *token.Service,
 	cookieService *cookie.Service,
-	authRepo *repo2.Service,
-	userRepo *repo.Service,
-	pollRepo *repo3.Service,
-	pollHandler *handler2.Service,
+	authRepo repo.Repository,
+	userRepo repo2.Repository,
+	pollRepo repo3.Repository,
+	pollHandler *handler3.Service,
+	auditRepo repo4.Repository,
+	auditHandler *handler.Service,
+	healthHandler *handler4.Service,
+	rateLimiter *ratelimit.Service,
 ) *Dependencies {
 	return &Dependencies{
+		Config:        config2,
+		MongoClient:   mongoClient,
+		PostgresDB:    postgresDB,
+		StorageGate:   storageGate,
 		Logger:        logger2,
+		Metrics:       metrics2,
+		Tracing:       tracing2,
 		AuthHandler:   authHandler,
 		TokenService:  tokenService,
 		CookieService: cookieService,
@@ -132,5 +216,9 @@ func packageDependencies(logger2 *logger.Service,
 		UserRepo:      userRepo,
 		PollRepo:      pollRepo,
 		PollHandler:   pollHandler,
+		AuditRepo:     auditRepo,
+		AuditHandler:  auditHandler,
+		HealthHandler: healthHandler,
+		RateLimiter:   rateLimiter,
 	}
 }
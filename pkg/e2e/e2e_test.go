@@ -0,0 +1,421 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"survey-api/pkg/apperror"
+	auditmodel "survey-api/pkg/audit/model"
+	"survey-api/pkg/auth/model"
+	"survey-api/pkg/config"
+	pollmodel "survey-api/pkg/poll/model"
+	"survey-api/pkg/requestid"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	alice = map[string]string{
+		"first_name": "Alice",
+		"user_name":  "alice",
+		"email":      "alice@example.com",
+		"password":   "Secret123",
+	}
+	bob = map[string]string{
+		"first_name": "Bob",
+		"user_name":  "bob",
+		"email":      "bob@example.com",
+		"password":   "Secret456",
+	}
+	newPoll = map[string]interface{}{
+		"content":    "Tabs or spaces?",
+		"visibility": "public",
+		"options": []map[string]string{
+			{"content": "Tabs"},
+			{"content": "Spaces"},
+		},
+	}
+)
+
+func TestUserJourney(t *testing.T) {
+	h := newHarness(t)
+	h.do(http.MethodPost, "/register", "", alice).expect(t, http.StatusAccepted)
+
+	var auth model.AuthUser
+	h.do(http.MethodPost, "/login", "", map[string]string{
+		"identifier": "Alice@Example.com",
+		"password":   alice["password"],
+	}).expect(t, http.StatusOK).decode(t, &auth)
+	if len(auth.Token) == 0 || auth.User.UserName != "alice" {
+		t.Fatalf("expected a token for alice, got %+v", auth)
+	}
+
+	if h.sessionCookie() == nil {
+		t.Fatal("expected the session cookie to be set on login")
+	}
+
+	var poll pollmodel.PollClient
+	h.do(http.MethodPost, "/poll", auth.Token, newPoll).expect(t, http.StatusOK).decode(t, &poll)
+	if len(poll.Id) == 0 || len(poll.Options) != 2 {
+		t.Fatalf("expected the created poll, got %+v", poll)
+	}
+
+	h.do(http.MethodPut, "/poll/vote", auth.Token, map[string]string{
+		"poll_id": poll.Id,
+		"index":   "1",
+	}).expect(t, http.StatusOK).decode(t, &poll)
+	if poll.Participants != 1 || poll.Options[1].Count != 1 {
+		t.Fatalf("expected the vote to be counted, got %+v", poll)
+	}
+
+	// The token of the session is still valid, so it is returned again.
+	var refreshed model.AuthUser
+	h.do(http.MethodPost, "/token/refresh", "", nil).expect(t, http.StatusOK).decode(t, &refreshed)
+	if refreshed.Token != auth.Token || refreshed.User.Id != auth.User.Id {
+		t.Fatalf("expected the current token, got %+v", refreshed)
+	}
+
+	h.do(http.MethodPost, "/logout", refreshed.Token, nil).expect(t, http.StatusOK)
+	if h.sessionCookie() != nil {
+		t.Fatal("expected the session cookie to be removed on logout")
+	}
+
+	h.do(http.MethodPost, "/token/refresh", "", nil).expect(t, http.StatusUnauthorized)
+}
+
+func TestRefreshReplacesExpiredToken(t *testing.T) {
+	h := newHarness(t)
+	auth := h.registerAndLogin(t, alice)
+
+	// Let the token of the session expire.
+	session, err := h.authRepo.FindOne(context.Background(), &model.Session{Token: auth.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session.Token = h.expiredToken(t, auth.User.Id)
+	_, err = h.authRepo.ReplaceOne(context.Background(), session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var refreshed model.AuthUser
+	response := h.do(http.MethodPost, "/token/refresh", "", nil).expect(t, http.StatusOK)
+	response.decode(t, &refreshed)
+	if refreshed.Token == session.Token || len(refreshed.Token) == 0 {
+		t.Fatalf("expected a new token, got %s", refreshed.Token)
+	}
+
+	if len(response.header.Get("Set-Cookie")) == 0 || h.sessionCookie() == nil {
+		t.Fatal("expected the session cookie to be renewed")
+	}
+
+	h.do(http.MethodPost, "/poll", refreshed.Token, newPoll).expect(t, http.StatusOK)
+}
+
+func TestErrors(t *testing.T) {
+	h := newHarness(t)
+	auth := h.registerAndLogin(t, alice)
+
+	var poll pollmodel.PollClient
+	h.do(http.MethodPost, "/poll", auth.Token, newPoll).expect(t, http.StatusOK).decode(t, &poll)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   interface{}
+		status int
+	}{
+		{"wrong method", http.MethodGet, "/login", "", nil, http.StatusMethodNotAllowed},
+		{"unknown route", http.MethodGet, "/unknown", "", nil, http.StatusNotFound},
+		{"bad JSON", http.MethodPost, "/login", "", `{"identifier":`, http.StatusBadRequest},
+		{"wrong password", http.MethodPost, "/login", "", map[string]string{"identifier": "alice", "password": "Wrong1234"}, http.StatusUnauthorized},
+		{"invalid registration", http.MethodPost, "/register", "", map[string]string{"user_name": "a@b"}, http.StatusUnprocessableEntity},
+		{"taken user name", http.MethodPost, "/register", "", alice, http.StatusConflict},
+		{"missing token", http.MethodPost, "/poll", "", newPoll, http.StatusUnauthorized},
+		{"expired token", http.MethodPost, "/poll", h.expiredToken(t, auth.User.Id), newPoll, http.StatusUnauthorized},
+		{"forged token", http.MethodPost, "/poll", signToken(t, auth.User.Id, "another-key-which-is-long-enough-for-hs256", time.Now().Add(time.Minute)), newPoll, http.StatusUnauthorized},
+		{"unknown poll", http.MethodPut, "/poll/vote", auth.Token, map[string]string{"poll_id": "000000000000000000000000", "index": "0"}, http.StatusNotFound},
+		{"invalid index", http.MethodPut, "/poll/vote", auth.Token, map[string]string{"poll_id": poll.Id, "index": "5"}, http.StatusUnprocessableEntity},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h.do(test.method, test.path, test.token, test.body).expect(t, test.status)
+		})
+	}
+}
+
+// A registration with a taken email is answered like a successful one, so
+// that it does not tell whether the email has an account, and its owner is
+// notified instead.
+func TestRegistrationWithTakenEmail(t *testing.T) {
+	mails := newSmtpServer(t)
+	h := newHarnessWith(t, func(conf *config.Config) {
+		conf.Smtp.Host, conf.Smtp.Port = mails.addr()
+	})
+	registered := h.do(http.MethodPost, "/register", "", alice).expect(t, http.StatusAccepted)
+
+	attempt := map[string]string{}
+	for key, value := range bob {
+		attempt[key] = value
+	}
+
+	attempt["email"] = alice["email"]
+	response := h.do(http.MethodPost, "/register", "", attempt).expect(t, http.StatusAccepted)
+	if len(response.body) != 0 || len(registered.body) != 0 {
+		t.Errorf("expected no body, got %q and %q", registered.body, response.body)
+	}
+
+	if len(response.header.Values("Set-Cookie")) != 0 || h.sessionCookie() != nil {
+		t.Errorf("expected no cookie, got %v", response.header.Values("Set-Cookie"))
+	}
+
+	if count := mails.received(alice["email"], "Welcome to Survey"); count != 1 {
+		t.Errorf("expected one welcome, got %d", count)
+	}
+
+	if count := mails.received(alice["email"], "Registration attempt"); count != 1 {
+		t.Errorf("expected the owner to be notified of the attempt once, got %d notifications", count)
+	}
+
+	h.do(http.MethodPost, "/login", "", map[string]string{
+		"identifier": bob["user_name"],
+		"password":   bob["password"],
+	}).expect(t, http.StatusUnauthorized)
+}
+
+func TestRequestId(t *testing.T) {
+	h := newHarness(t)
+	response := h.do(http.MethodGet, "/unknown", "", nil).expect(t, http.StatusNotFound)
+	requestId := response.header.Get(requestid.Header)
+	if len(requestId) == 0 {
+		t.Fatal("expected a generated request id")
+	}
+
+	var problem apperror.Problem
+	response.decode(t, &problem)
+	if problem.RequestId != requestId {
+		t.Fatalf("expected the request id %s in the problem, got %+v", requestId, problem)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, h.server.URL+"/healthz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Header.Set(requestid.Header, "client-request-1")
+	response = h.send(request)
+	if response.expect(t, http.StatusOK).header.Get(requestid.Header) != "client-request-1" {
+		t.Fatalf("expected the request id of the client to be echoed, got %s", response.header.Get(requestid.Header))
+	}
+}
+
+func TestDoubleVote(t *testing.T) {
+	h := newHarness(t)
+	owner := h.registerAndLogin(t, alice)
+	var poll pollmodel.PollClient
+	h.do(http.MethodPost, "/poll", owner.Token, newPoll).expect(t, http.StatusOK).decode(t, &poll)
+
+	voter := h.registerAndLogin(t, bob)
+	pollVote := map[string]string{"poll_id": poll.Id, "index": "0"}
+	h.do(http.MethodPut, "/poll/vote", voter.Token, pollVote).expect(t, http.StatusOK)
+	h.do(http.MethodPut, "/poll/vote", voter.Token, pollVote).expect(t, http.StatusConflict)
+
+	h.do(http.MethodDelete, "/poll/"+poll.Id, voter.Token, nil).expect(t, http.StatusForbidden)
+	h.do(http.MethodDelete, "/poll/"+poll.Id, owner.Token, nil).expect(t, http.StatusOK)
+	h.do(http.MethodPut, "/poll/vote", owner.Token, pollVote).expect(t, http.StatusNotFound)
+}
+
+func TestMetrics(t *testing.T) {
+	h := newHarness(t)
+	auth := h.registerAndLogin(t, alice)
+	h.do(http.MethodPost, "/login", "", map[string]string{
+		"identifier": "alice",
+		"password":   "Wrong1234",
+	}).expect(t, http.StatusUnauthorized)
+	h.do(http.MethodPost, "/poll", auth.Token, newPoll).expect(t, http.StatusOK)
+	h.do(http.MethodDelete, "/poll/000000000000000000000000", auth.Token, nil).expect(t, http.StatusNotFound)
+	h.do(http.MethodGet, "/unknown", "", nil).expect(t, http.StatusNotFound)
+
+	body := string(h.do(http.MethodGet, "/metrics", "", nil).expect(t, http.StatusOK).body)
+	expected := []string{
+		`survey_http_requests_total{method="POST",route="/login",status="401"} 1`,
+		`survey_http_requests_total{method="POST",route="/poll",status="200"} 1`,
+		`survey_http_requests_total{method="DELETE",route="/poll/{id}",status="404"} 1`,
+		`survey_http_requests_total{method="other",route="unmatched",status="404"} 1`,
+		`survey_storage_operation_duration_seconds_count{method="InsertOne",outcome="ok",repo="user",storage="memory"} 1`,
+		`survey_registrations_total 1`,
+		`survey_logins_total{result="failed"} 1`,
+		`survey_logins_total{result="succeeded"} 1`,
+		`survey_polls_created_total 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("expected %s in the metrics", line)
+		}
+	}
+
+	h.config.Metrics.Token = "metrics-token"
+	h.do(http.MethodGet, "/metrics", "", nil).expect(t, http.StatusUnauthorized)
+	h.do(http.MethodGet, "/metrics", "metrics-token", nil).expect(t, http.StatusOK)
+}
+
+func TestAudit(t *testing.T) {
+	h := newHarness(t)
+	admin := h.registerAndLogin(t, alice)
+	h.config.Admin.UserIds = admin.User.Id
+	h.do(http.MethodPost, "/login", "", map[string]string{
+		"identifier": "bob",
+		"password":   "Wrong1234",
+	}).expect(t, http.StatusUnauthorized)
+
+	var poll pollmodel.PollClient
+	h.do(http.MethodPost, "/poll", admin.Token, newPoll).expect(t, http.StatusOK).decode(t, &poll)
+	user := h.registerAndLogin(t, bob)
+	h.do(http.MethodDelete, "/poll/"+poll.Id, user.Token, nil).expect(t, http.StatusForbidden)
+	h.do(http.MethodGet, "/audit", user.Token, nil).expect(t, http.StatusForbidden)
+	h.do(http.MethodGet, "/audit", "", nil).expect(t, http.StatusUnauthorized)
+	h.do(http.MethodGet, "/audit?from=yesterday", admin.Token, nil).expect(t, http.StatusUnprocessableEntity)
+
+	var result struct {
+		Events []auditmodel.ClientEvent `json:"events"`
+	}
+	h.do(http.MethodGet, "/audit?action=login&outcome=failure", admin.Token, nil).expect(t, http.StatusOK).decode(t, &result)
+	if len(result.Events) != 1 || result.Events[0].Target != "bob" || len(result.Events[0].ActorId) != 0 {
+		t.Fatalf("expected the failed login of an unknown user, got %+v", result.Events)
+	}
+
+	if len(result.Events[0].Ip) == 0 || len(result.Events[0].UserAgent) == 0 || len(result.Events[0].RequestId) == 0 {
+		t.Fatalf("expected the client and the request of the event, got %+v", result.Events[0])
+	}
+
+	h.do(http.MethodGet, "/audit?actor_id="+user.User.Id, admin.Token, nil).expect(t, http.StatusOK).decode(t, &result)
+	var actions []string
+	for _, event := range result.Events {
+		actions = append(actions, event.Action+":"+event.Outcome)
+	}
+
+	expected := []string{"poll.delete:failure", "login:success", "register:success"}
+	if strings.Join(actions, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v, got %v", expected, actions)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := newHarnessWith(t, func(conf *config.Config) {
+		conf.RateLimit.Limits = "POST /register=2/1h, POST /poll=1/1h"
+	})
+	aliceAuth := h.registerAndLogin(t, alice)
+	bobAuth := h.registerAndLogin(t, bob)
+
+	response := h.do(http.MethodPost, "/register", "", map[string]string{}).expect(t, http.StatusTooManyRequests)
+	if response.header.Get("X-RateLimit-Limit") != "2" || response.header.Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("expected the exhausted limit, got %v", response.header)
+	}
+
+	// One request is refilled every 30 minutes.
+	if response.header.Get("Retry-After") != "1800" {
+		t.Fatalf("expected to retry after 1800 seconds, got %s", response.header.Get("Retry-After"))
+	}
+
+	// Authenticated requests are limited per user, not per address.
+	response = h.do(http.MethodPost, "/poll", aliceAuth.Token, newPoll).expect(t, http.StatusOK)
+	if response.header.Get("X-RateLimit-Remaining") != "0" || response.header.Get("X-RateLimit-Reset") != "3600" {
+		t.Fatalf("expected the remaining requests and their reset, got %v", response.header)
+	}
+
+	h.do(http.MethodPost, "/poll", aliceAuth.Token, newPoll).expect(t, http.StatusTooManyRequests)
+	h.do(http.MethodPost, "/poll", bobAuth.Token, newPoll).expect(t, http.StatusOK)
+
+	// Routes without a limit are left alone.
+	response = h.do(http.MethodPost, "/login", "", map[string]string{}).expect(t, http.StatusUnprocessableEntity)
+	if len(response.header.Get("X-RateLimit-Limit")) != 0 {
+		t.Fatalf("expected no limit on login, got %v", response.header)
+	}
+}
+
+func TestCrossOrigin(t *testing.T) {
+	h := newHarnessWith(t, func(conf *config.Config) {
+		conf.Cors.AllowedOrigins = "https://survey.example.com"
+		conf.Cors.AllowCredentials = true
+		conf.Cookie.SameSite = "none"
+	})
+	auth := h.registerAndLogin(t, alice)
+
+	request, err := http.NewRequest(http.MethodOptions, h.server.URL+"/poll/42", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Header.Set("Origin", "https://survey.example.com")
+	request.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	request.Header.Set("Access-Control-Request-Headers", "authorization")
+	response := h.send(request).expect(t, http.StatusNoContent)
+	if response.header.Get("Access-Control-Allow-Origin") != "https://survey.example.com" ||
+		response.header.Get("Access-Control-Allow-Credentials") != "true" ||
+		!strings.Contains(response.header.Get("Access-Control-Allow-Methods"), http.MethodDelete) ||
+		!strings.Contains(response.header.Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Fatalf("expected the preflight to allow the request, got %v", response.header)
+	}
+
+	request, err = http.NewRequest(http.MethodPost, h.server.URL+"/login", strings.NewReader(`{"identifier": "alice", "password": "Secret123"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Header.Set("Origin", "https://survey.example.com")
+	response = h.send(request).expect(t, http.StatusOK)
+	if !strings.Contains(response.header.Get("Access-Control-Expose-Headers"), requestid.Header) {
+		t.Fatalf("expected the request id to be exposed, got %v", response.header)
+	}
+
+	if !strings.Contains(response.header.Get("Set-Cookie"), "SameSite=None") {
+		t.Fatalf("expected the configured SameSite policy, got %s", response.header.Get("Set-Cookie"))
+	}
+
+	response = h.do(http.MethodPost, "/poll", auth.Token, newPoll).expect(t, http.StatusOK)
+	expected := map[string]string{
+		"X-Content-Type-Options":    "nosniff",
+		"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+		"Strict-Transport-Security": "max-age=31536000",
+	}
+	for name, value := range expected {
+		if response.header.Get(name) != value {
+			t.Errorf("expected %s to be %q, got %q", name, value, response.header.Get(name))
+		}
+	}
+}
+
+func (h *harness) registerAndLogin(t *testing.T, user map[string]string) *model.AuthUser {
+	t.Helper()
+	h.do(http.MethodPost, "/register", "", user).expect(t, http.StatusAccepted)
+	auth := &model.AuthUser{}
+	h.do(http.MethodPost, "/login", "", map[string]string{
+		"identifier": user["user_name"],
+		"password":   user["password"],
+	}).expect(t, http.StatusOK).decode(t, auth)
+	return auth
+}
+
+func (h *harness) expiredToken(t *testing.T, userId string) string {
+	t.Helper()
+	return signToken(t, userId, h.config.Auth.JwtKey, time.Now().Add(-time.Minute))
+}
+
+func signToken(t *testing.T, userId string, key string, expiresAt time.Time) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
+		Subject:   userId,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	signed, err := token.SignedString([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
@@ -0,0 +1,226 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	auditapi "survey-api/pkg/audit/api"
+	audithandler "survey-api/pkg/audit/handler"
+	auditrepo "survey-api/pkg/audit/repo"
+	"survey-api/pkg/auth/api/login"
+	"survey-api/pkg/auth/api/logout"
+	"survey-api/pkg/auth/api/refresh"
+	"survey-api/pkg/auth/api/register"
+	"survey-api/pkg/auth/cookie"
+	authhandler "survey-api/pkg/auth/handler"
+	"survey-api/pkg/auth/password"
+	authrepo "survey-api/pkg/auth/repo"
+	"survey-api/pkg/auth/token"
+	"survey-api/pkg/config"
+	"survey-api/pkg/di"
+	"survey-api/pkg/endpoint"
+	healthapi "survey-api/pkg/health/api"
+	healthhandler "survey-api/pkg/health/handler"
+	"survey-api/pkg/logger"
+	"survey-api/pkg/metrics"
+	metricsapi "survey-api/pkg/metrics/api"
+	"survey-api/pkg/notification"
+	pollapi "survey-api/pkg/poll/api"
+	"survey-api/pkg/poll/api/vote"
+	pollhandler "survey-api/pkg/poll/handler"
+	pollrepo "survey-api/pkg/poll/repo"
+	"survey-api/pkg/ratelimit"
+	"survey-api/pkg/storage"
+	"survey-api/pkg/tracing"
+	userrepo "survey-api/pkg/user/repo"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionCookieName = "survey-session"
+)
+
+// harness serves every endpoint over TLS, so that the secure session cookie
+// is kept by the cookie jar of the client, with in-memory repositories.
+type harness struct {
+	t        *testing.T
+	server   *httptest.Server
+	client   *http.Client
+	config   *config.Config
+	authRepo authrepo.Repository
+}
+
+func newHarness(t *testing.T) *harness {
+	return newHarnessWith(t, func(conf *config.Config) {})
+}
+
+// newHarnessWith lets configure change the configuration of the test
+// server. Rate limiting is disabled unless configured, since every test
+// client has the same address.
+func newHarnessWith(t *testing.T, configure func(*config.Config)) *harness {
+	conf := config.Default()
+	conf.Storage = config.MemoryStorage
+	conf.Auth.JwtKey = "e2e-jwt-key-which-is-long-enough-for-hs256"
+	conf.Auth.SessionKey = "e2e-session-key-which-is-long-enough-too"
+	conf.Password.BcryptCost = bcrypt.MinCost
+	conf.RateLimit.Limits = ""
+	configure(conf)
+
+	logger := logger.NewWriter(ioutil.Discard, logger.LevelInfo)
+	metrics := metrics.New()
+	tracing, err := tracing.New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenService := token.New(conf)
+	cookieService := cookie.New(conf)
+	userRepo := userrepo.NewInstrumented(userrepo.NewMemory(), metrics, tracing, conf.Storage)
+	authRepo := authrepo.NewInstrumented(authrepo.NewMemory(), metrics, tracing, conf.Storage)
+	pollRepo := pollrepo.NewInstrumented(pollrepo.NewMemory(), metrics, tracing, conf.Storage)
+	auditHandler := audithandler.New(logger, auditrepo.NewMemory())
+	authHandler := authhandler.New(
+		logger,
+		metrics,
+		tracing,
+		auditHandler,
+		userRepo,
+		authRepo,
+		tokenService,
+		cookieService,
+		notification.New(logger, conf),
+		password.New(conf),
+	)
+	pollHandler := pollhandler.New(pollRepo, metrics, tracing, auditHandler)
+	rateLimiter, err := ratelimit.New(conf, ratelimit.NewMemory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt := endpoint.New(&di.Dependencies{
+		Config:      conf,
+		Logger:      logger,
+		Metrics:     metrics,
+		Tracing:     tracing,
+		AuthHandler: authHandler,
+		RateLimiter: rateLimiter,
+		StorageGate: storage.NewGate(nil),
+	})
+	register.Init(rt, authHandler)
+	login.Init(rt, authHandler)
+	logout.Init(rt, authHandler, auditHandler, authRepo, tokenService, cookieService)
+	refresh.Init(rt, cookieService, tokenService, authRepo, userRepo, authHandler, auditHandler)
+	pollapi.Init(rt, authHandler, pollHandler)
+	vote.Init(rt, authHandler, pollHandler)
+	healthapi.Init(rt, healthhandler.New(logger, nil, nil, nil, conf))
+	metricsapi.Init(rt, conf, metrics)
+	auditapi.Init(rt, conf, authHandler, auditHandler)
+
+	server := httptest.NewTLSServer(rt)
+	t.Cleanup(server.Close)
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := server.Client()
+	client.Jar = jar
+	return &harness{
+		t:        t,
+		server:   server,
+		client:   client,
+		config:   conf,
+		authRepo: authRepo,
+	}
+}
+
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// do sends body as is when it is a string, and as JSON otherwise.
+func (h *harness) do(method string, path string, token string, body interface{}) *response {
+	h.t.Helper()
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(body)
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+
+		reader = bytes.NewBuffer(encoded)
+	}
+
+	request, err := http.NewRequest(method, h.server.URL+path, reader)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	if len(token) != 0 {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return h.send(request)
+}
+
+func (h *harness) send(request *http.Request) *response {
+	h.t.Helper()
+	result, err := h.client.Do(request)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	defer result.Body.Close()
+	responseBody, err := ioutil.ReadAll(result.Body)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	return &response{status: result.StatusCode, header: result.Header, body: responseBody}
+}
+
+func (h *harness) sessionCookie() *http.Cookie {
+	h.t.Helper()
+	refreshUrl, err := url.Parse(h.server.URL + "/token/refresh")
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	for _, cookie := range h.client.Jar.Cookies(refreshUrl) {
+		if cookie.Name == sessionCookieName {
+			return cookie
+		}
+	}
+
+	return nil
+}
+
+func (r *response) expect(t *testing.T, status int) *response {
+	t.Helper()
+	if r.status != status {
+		t.Fatalf("expected status %d, got %d: %s", status, r.status, r.body)
+	}
+
+	return r
+}
+
+func (r *response) decode(t *testing.T, v interface{}) {
+	t.Helper()
+	err := json.Unmarshal(r.body, v)
+	if err != nil {
+		t.Fatalf("%v: %s", err, r.body)
+	}
+}
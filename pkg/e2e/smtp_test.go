@@ -0,0 +1,110 @@
+package e2e
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type mail struct {
+	to      []string
+	message []string
+}
+
+// smtpServer accepts every mail and keeps it, to check the notifications.
+type smtpServer struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	mails    []mail
+}
+
+func newSmtpServer(t *testing.T) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &smtpServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(textproto.NewConn(conn))
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) addr() (string, string) {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return host, port
+}
+
+// received returns the mails sent to the address with the subject.
+func (s *smtpServer) received(to string, subject string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	count := 0
+	for _, mail := range s.mails {
+		if len(mail.to) == 1 && mail.to[0] == to && contains(mail.message, "Subject: "+subject) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (s *smtpServer) serve(conn *textproto.Conn) {
+	defer conn.Close()
+	conn.PrintfLine("220 localhost")
+	var current mail
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO", "MAIL":
+			conn.PrintfLine("250 OK")
+		case "RCPT":
+			address := line[strings.Index(line, "<")+1 : strings.LastIndex(line, ">")]
+			current.to = append(current.to, address)
+			conn.PrintfLine("250 OK")
+		case "DATA":
+			conn.PrintfLine("354 Go ahead")
+			current.message, err = conn.ReadDotLines()
+			if err != nil {
+				return
+			}
+
+			s.mutex.Lock()
+			s.mails = append(s.mails, current)
+			s.mutex.Unlock()
+			current = mail{}
+			conn.PrintfLine("250 OK")
+		case "QUIT":
+			conn.PrintfLine("221 Bye")
+			return
+		default:
+			conn.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func contains(lines []string, value string) bool {
+	for _, line := range lines {
+		if line == value {
+			return true
+		}
+	}
+
+	return false
+}
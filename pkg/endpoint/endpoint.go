@@ -0,0 +1,93 @@
+package endpoint
+
+import (
+	"net/http"
+	"survey-api/pkg/apperror"
+	audithandler "survey-api/pkg/audit/handler"
+	"survey-api/pkg/di"
+	"survey-api/pkg/logger"
+	"survey-api/pkg/middleware"
+	"survey-api/pkg/router"
+	"sync"
+)
+
+var (
+	// The routes, which never use the storage, so that probes and scrapes
+	// are answered while it is down.
+	withoutStorage = []router.Route{
+		{Method: http.MethodGet, Pattern: "/healthz"},
+		{Method: http.MethodGet, Pattern: "/readyz"},
+		{Method: http.MethodGet, Pattern: "/metrics"},
+	}
+)
+
+// Routes registers the routes of an endpoint package on the router.
+type Routes func(*router.Router, *di.Dependencies)
+
+// New creates the router shared by the serverless functions and the
+// standalone server.
+func New(container *di.Dependencies) *router.Router {
+	rt := router.New(container.Logger)
+	rt.Use(
+		middleware.RequestId(),
+		middleware.ClientIp(&container.Config.Server),
+		middleware.AccessLog(container.Logger),
+		middleware.SecurityHeaders(&container.Config.Security),
+		middleware.Cors(&container.Config.Cors),
+		middleware.Tracing(container.Tracing),
+		middleware.Metrics(container.Metrics),
+		audithandler.TrackClient,
+		middleware.WaitForStorage(container.StorageGate, withoutStorage),
+		middleware.RateLimit(container.Logger, container.RateLimiter, container.AuthHandler.AuthToken),
+		middleware.MaxBodySize(container.Config.Server.MaxBodyBytes),
+	)
+	return rt
+}
+
+// Serverless creates the entry point of a serverless function, which
+// serves the routes of a single endpoint package. The dependencies are
+// resolved on the first request instead of when the package is loaded.
+// While they cannot be resolved, requests fail with 503 and the next
+// request tries again. The storage is not reached while they are resolved,
+// but on the first request using it, so routes which do not use it, like
+// /healthz, are served while it is down.
+func Serverless(routes Routes) func(http.ResponseWriter, *http.Request) {
+	var mutex sync.Mutex
+	var handler http.Handler
+	var container *di.Dependencies
+	build := func() (http.Handler, *di.Dependencies, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if handler != nil {
+			return handler, container, nil
+		}
+
+		deps, err := di.Container()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rt := New(deps)
+		routes(rt, deps)
+		handler = rt
+		container = deps
+		return handler, container, nil
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler, container, err := build()
+		if err != nil {
+			di.Logger().Error("Failed to create the dependencies", logger.Fields{"error": err})
+			apperror.Write(w, r, apperror.ErrUnavailable)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+
+		// The function may be frozen once it responded.
+		err = container.Tracing.Flush(r.Context())
+		if err != nil {
+			container.Logger.Error("Failed to export the spans", logger.Fields{"error": err})
+		}
+	}
+}
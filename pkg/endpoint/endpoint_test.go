@@ -0,0 +1,78 @@
+package endpoint_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"survey-api/pkg/di"
+	"survey-api/pkg/endpoint"
+	healthapi "survey-api/pkg/health/api"
+	"survey-api/pkg/router"
+	usermodel "survey-api/pkg/user/model"
+	"testing"
+)
+
+const (
+	testKey = "a-key-which-is-long-enough-for-hs256"
+)
+
+func routes(rt *router.Router, container *di.Dependencies) {
+	healthapi.Init(rt, container.HealthHandler)
+	rt.Handle(http.MethodGet, "/user", func(w http.ResponseWriter, r *http.Request) error {
+		user, err := container.UserRepo.FindOne(r.Context(), &usermodel.User{UserName: "alice"})
+		if err != nil {
+			return err
+		}
+
+		return router.WriteJSON(w, http.StatusOK, user)
+	})
+}
+
+func serve(handler func(http.ResponseWriter, *http.Request), path string) int {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w.Code
+}
+
+// The container is global, so a single test goes through every state.
+func TestServerlessRetriesAndServesWithoutStorage(t *testing.T) {
+	handler := endpoint.Serverless(routes)
+	for _, key := range []string{"CONFIG_FILE", "JWT_KEY", "SESSION_KEY"} {
+		os.Unsetenv(key)
+	}
+
+	env := map[string]string{
+		"STORAGE":          "postgres",
+		"POSTGRES_URL":     "postgres://survey@127.0.0.1:1/survey?sslmode=disable&connect_timeout=1",
+		"LOG_LEVEL":        "error",
+		"TRACING_EXPORTER": "none",
+	}
+	for key, value := range env {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+
+	// Without the keys, the configuration is invalid.
+	if status := serve(handler, "/healthz"); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a configuration, got %d", status)
+	}
+
+	os.Setenv("JWT_KEY", testKey)
+	defer os.Unsetenv("JWT_KEY")
+	os.Setenv("SESSION_KEY", testKey)
+	defer os.Unsetenv("SESSION_KEY")
+
+	// The next request tries again, and the server, which is down, only
+	// fails the routes using it.
+	if status := serve(handler, "/healthz"); status != http.StatusOK {
+		t.Fatalf("expected the liveness to be served without the storage, got %d", status)
+	}
+
+	if status := serve(handler, "/readyz"); status != http.StatusServiceUnavailable {
+		t.Errorf("expected the readiness to be down, got %d", status)
+	}
+
+	if status := serve(handler, "/user"); status != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while the storage is down, got %d", status)
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"survey-api/pkg/di"
+	"survey-api/pkg/endpoint"
+	healthhandler "survey-api/pkg/health/handler"
+	"survey-api/pkg/router"
+)
+
+var handler = endpoint.Serverless(Routes)
+
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler(w, r)
+}
+
+func Routes(rt *router.Router, container *di.Dependencies) {
+	Init(rt, container.HealthHandler)
+}
+
+func Init(rt *router.Router, healthHandler *healthhandler.Service) {
+	// The process is alive as long as it responds.
+	rt.Handle(http.MethodGet, "/healthz", func(w http.ResponseWriter, r *http.Request) error {
+		return router.WriteJSON(w, http.StatusOK, &healthhandler.Report{Status: healthhandler.StatusUp})
+	})
+	rt.Handle(http.MethodGet, "/readyz", func(w http.ResponseWriter, r *http.Request) error {
+		report := healthHandler.Check(r.Context())
+		status := http.StatusOK
+		if report.Status != healthhandler.StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+
+		return router.WriteJSON(w, status, report)
+	})
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"survey-api/pkg/config"
+	"survey-api/pkg/logger"
+	"survey-api/pkg/migration"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	pingTimeout = 2 * time.Second
+)
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]*Check `json:"checks"`
+}
+
+// Check leaves out the error of a failed check, which may name hosts of
+// the infrastructure. The error is logged instead.
+type Check struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+type Service struct {
+	logger      *logger.Service
+	mongoClient *mongo.Client
+	postgresDB  *sql.DB
+	migrations  *migration.Service
+	config      *config.Config
+}
+
+// New creates the service with a check for each storage, and for the
+// migrations, which are not nil. Only the configured storage is set, and
+// none is for the memory storage.
+func New(logger *logger.Service, mongoClient *mongo.Client, postgresDB *sql.DB, migrations *migration.Service, config *config.Config) *Service {
+	return &Service{logger: logger, mongoClient: mongoClient, postgresDB: postgresDB, migrations: migrations, config: config}
+}
+
+// Check runs every dependency check concurrently. The report is up only
+// when every check is up.
+func (s *Service) Check(ctx context.Context) *Report {
+	checks := map[string]func(context.Context) error{
+		"secrets": s.checkSecrets,
+	}
+	if s.mongoClient != nil {
+		checks["mongodb"] = s.checkMongodb
+	}
+
+	if s.postgresDB != nil {
+		checks["postgres"] = s.checkPostgres
+	}
+
+	if s.migrations != nil {
+		checks["migrations"] = s.checkMigrations
+	}
+
+	type result struct {
+		name  string
+		check *Check
+	}
+	results := make(chan result, len(checks))
+	for name, check := range checks {
+		go func(name string, check func(context.Context) error) {
+			results <- result{name: name, check: s.runCheck(ctx, name, check)}
+		}(name, check)
+	}
+
+	report := &Report{Status: StatusUp, Checks: make(map[string]*Check, len(checks))}
+	for range checks {
+		result := <-results
+		report.Checks[result.name] = result.check
+		if result.check.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+func (s *Service) checkMongodb(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	return s.mongoClient.Ping(ctx, readpref.Primary())
+}
+
+func (s *Service) checkPostgres(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	return s.postgresDB.PingContext(ctx)
+}
+
+// Pending migrations are down, as the storage may lack the indexes, which
+// the application relies on.
+func (s *Service) checkMigrations(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	return s.migrations.Check(ctx)
+}
+
+func (s *Service) checkSecrets(ctx context.Context) error {
+	secrets := []struct {
+		name  string
+		value string
+	}{
+		{"JWT_KEY", s.config.Auth.JwtKey},
+		{"SESSION_KEY", s.config.Auth.SessionKey},
+	}
+	var missing []string
+	for _, secret := range secrets {
+		if len(secret.value) == 0 {
+			missing = append(missing, secret.name)
+		}
+	}
+
+	if len(missing) != 0 {
+		return &missingSecretsError{secrets: missing}
+	}
+
+	return nil
+}
+
+func (s *Service) runCheck(ctx context.Context, name string, check func(context.Context) error) *Check {
+	start := time.Now()
+	err := check(ctx)
+	result := &Check{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		s.logger.For(ctx).Error("Health check failed", logger.Fields{"check": name, "error": err})
+	}
+
+	return result
+}
+
+type missingSecretsError struct {
+	secrets []string
+}
+
+func (e *missingSecretsError) Error() string {
+	return "Missing " + strings.Join(e.secrets, ", ")
+}
@@ -1,15 +1,247 @@
+// Package logger writes structured log lines as JSON objects, one per
+// line, with a time, a level, a message and any number of fields.
+//
+// Every request gets a child logger with the fields of the request, which
+// handlers retrieve with For. Fields, which are only known later, like the
+// authenticated user, are added to the request with AddFields.
 package logger
 
 import (
-	"log"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"survey-api/pkg/config"
+	"sync"
+	"time"
 )
 
-type Service struct{}
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+const (
+	loggerKey contextKey = iota
+)
+
+var (
+	ErrUnknownLevel = errors.New("Unknown log level")
+
+	levelNames = map[Level]string{
+		LevelDebug: "debug",
+		LevelInfo:  "info",
+		LevelWarn:  "warn",
+		LevelError: "error",
+	}
+
+	// The output of the zero value.
+	defaultOutput = &output{writer: os.Stderr, now: time.Now}
+)
+
+type Level int
+
+type contextKey int
+
+// Fields are the structured context of a log line. Values are written as
+// JSON, errors as their message.
+type Fields map[string]interface{}
+
+// Service is safe for concurrent use. The zero value logs at the info
+// level to stderr.
+type Service struct {
+	output *output
+	level  Level
+	fields Fields
+	// The fields added to the request, shared by every child logger of
+	// the request. Nil outside of requests.
+	request *requestFields
+}
+
+type output struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	now    func() time.Time
+}
+
+type requestFields struct {
+	mutex  sync.Mutex
+	fields Fields
+}
+
+func New(config *config.Config) (*Service, error) {
+	level, err := ParseLevel(config.Log.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWriter(os.Stderr, level), nil
+}
 
-func (s *Service) Log(msg string) {
-	log.Println(msg)
+// NewWriter creates a logger, which writes the lines at or above the level
+// to the writer.
+func NewWriter(writer io.Writer, level Level) *Service {
+	return &Service{
+		output: &output{writer: writer, now: time.Now},
+		level:  level,
+	}
 }
 
-func (s *Service) LogErr(err error) {
-	log.Println(err)
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	return 0, ErrUnknownLevel
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// With returns a child logger, which adds the fields to every line.
+func (s *Service) With(fields Fields) *Service {
+	child := *s
+	child.fields = merge(s.fields, fields)
+	return &child
+}
+
+// NewContext returns a context, which carries a child logger for a request
+// and the fields added to the request.
+func (s *Service) NewContext(ctx context.Context, fields Fields) context.Context {
+	child := s.With(fields)
+	child.request = &requestFields{}
+	return context.WithValue(ctx, loggerKey, child)
+}
+
+// For returns the logger of the request of the context, or s outside of
+// requests.
+func (s *Service) For(ctx context.Context) *Service {
+	logger, ok := ctx.Value(loggerKey).(*Service)
+	if !ok {
+		return s
+	}
+
+	return logger
+}
+
+// AddFields adds fields to every later line of the request of the context.
+// Outside of requests, it does nothing.
+func AddFields(ctx context.Context, fields Fields) {
+	logger, ok := ctx.Value(loggerKey).(*Service)
+	if !ok {
+		return
+	}
+
+	logger.request.mutex.Lock()
+	defer logger.request.mutex.Unlock()
+	logger.request.fields = merge(logger.request.fields, fields)
+}
+
+func (s *Service) Debug(msg string, fields ...Fields) {
+	s.write(LevelDebug, msg, fields)
+}
+
+func (s *Service) Info(msg string, fields ...Fields) {
+	s.write(LevelInfo, msg, fields)
+}
+
+func (s *Service) Warn(msg string, fields ...Fields) {
+	s.write(LevelWarn, msg, fields)
+}
+
+func (s *Service) Error(msg string, fields ...Fields) {
+	s.write(LevelError, msg, fields)
+}
+
+func (s *Service) write(level Level, msg string, fields []Fields) {
+	if s == nil || level < s.level {
+		return
+	}
+
+	lineFields := s.fields
+	if s.request != nil {
+		s.request.mutex.Lock()
+		lineFields = merge(lineFields, s.request.fields)
+		s.request.mutex.Unlock()
+	}
+
+	for _, extra := range fields {
+		lineFields = merge(lineFields, extra)
+	}
+
+	output := s.output
+	if output == nil {
+		output = defaultOutput
+	}
+
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+	output.writer.Write(encode(output.now(), level, msg, lineFields))
+}
+
+// The time, the level and the message come first, the fields follow in the
+// order of their keys.
+func encode(now time.Time, level Level, msg string, fields Fields) []byte {
+	var line bytes.Buffer
+	line.WriteString(`{"time":`)
+	writeValue(&line, now.UTC().Format(time.RFC3339Nano))
+	line.WriteString(`,"level":`)
+	writeValue(&line, level.String())
+	line.WriteString(`,"msg":`)
+	writeValue(&line, msg)
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		line.WriteByte(',')
+		writeValue(&line, key)
+		line.WriteByte(':')
+		writeValue(&line, redact(key, fields[key]))
+	}
+
+	line.WriteString("}\n")
+	return line.Bytes()
+}
+
+func writeValue(line *bytes.Buffer, value interface{}) {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+
+	line.Write(encoded)
+}
+
+func merge(fields Fields, extra Fields) Fields {
+	if len(extra) == 0 {
+		return fields
+	}
+
+	merged := make(Fields, len(fields)+len(extra))
+	for key, value := range fields {
+		merged[key] = value
+	}
+
+	for key, value := range extra {
+		merged[key] = value
+	}
+
+	return merged
 }
@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLogger(level Level) (*Service, *bytes.Buffer) {
+	var buffer bytes.Buffer
+	logger := NewWriter(&buffer, level)
+	logger.output.now = func() time.Time {
+		return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+
+	return logger, &buffer
+}
+
+func decodeLines(t *testing.T, buffer *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+
+		var decoded map[string]interface{}
+		err := json.Unmarshal([]byte(line), &decoded)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+
+		lines = append(lines, decoded)
+	}
+
+	return lines
+}
+
+func TestFormat(t *testing.T) {
+	logger, buffer := newTestLogger(LevelInfo)
+	logger.With(Fields{"b": 1}).Error("Failed", Fields{"error": errors.New("Broken"), "a": "value"})
+
+	expected := `{"time":"2020-01-02T03:04:05Z","level":"error","msg":"Failed","a":"value","b":1,"error":"Broken"}` + "\n"
+	if buffer.String() != expected {
+		t.Fatalf("expected %s, got %s", expected, buffer.String())
+	}
+}
+
+func TestLevels(t *testing.T) {
+	logger, buffer := newTestLogger(LevelWarn)
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	lines := decodeLines(t, buffer)
+	if len(lines) != 2 || lines[0]["msg"] != "warn" || lines[1]["msg"] != "error" {
+		t.Fatalf("expected only warn and error, got %v", lines)
+	}
+
+	for _, name := range []string{"debug", "INFO", "Warn", "error"} {
+		_, err := ParseLevel(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	_, err := ParseLevel("verbose")
+	if err != ErrUnknownLevel {
+		t.Errorf("expected %v, got %v", ErrUnknownLevel, err)
+	}
+}
+
+func TestRequestFields(t *testing.T) {
+	logger, buffer := newTestLogger(LevelInfo)
+	ctx := logger.NewContext(context.Background(), Fields{"request_id": "1"})
+	logger.For(ctx).Info("before")
+	AddFields(ctx, Fields{"user_id": "2"})
+	logger.For(ctx).With(Fields{"step": "child"}).Info("after")
+	logger.Info("outside")
+	AddFields(context.Background(), Fields{"user_id": "3"})
+
+	lines := decodeLines(t, buffer)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %v", lines)
+	}
+
+	if lines[0]["request_id"] != "1" || lines[0]["user_id"] != nil {
+		t.Errorf("expected only the request id, got %v", lines[0])
+	}
+
+	if lines[1]["request_id"] != "1" || lines[1]["user_id"] != "2" || lines[1]["step"] != "child" {
+		t.Errorf("expected the added fields, got %v", lines[1])
+	}
+
+	if lines[2]["request_id"] != nil || lines[2]["user_id"] != nil {
+		t.Errorf("expected no request fields, got %v", lines[2])
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	logger, buffer := newTestLogger(LevelInfo)
+	logger.Info("redacted", Fields{
+		"password":      "plain",
+		"refresh_token": "token",
+		"JWT_KEY":       "key",
+		"user_name":     "alice",
+		"body": map[string]interface{}{
+			"password":  "plain",
+			"user_name": "alice",
+		},
+		"header": http.Header{
+			"Authorization": {"Bearer token"},
+			"Cookie":        {"survey-session=value"},
+			"Accept":        {"application/json"},
+		},
+	})
+
+	output := buffer.String()
+	for _, secret := range []string{"plain", "Bearer", "survey-session", `"key"`} {
+		if strings.Contains(output, secret) {
+			t.Errorf("expected %s to be redacted, got %s", secret, output)
+		}
+	}
+
+	for _, kept := range []string{"alice", "application/json"} {
+		if !strings.Contains(output, kept) {
+			t.Errorf("expected %s to be kept, got %s", kept, output)
+		}
+	}
+}
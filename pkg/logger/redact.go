@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	redacted = "[REDACTED]"
+)
+
+var (
+	// Fields, whose lowercase keys contain any of these, are never written.
+	sensitiveKeys = []string{
+		"password",
+		"token",
+		"secret",
+		"cookie",
+		"authorization",
+		"session",
+		"jwt",
+		"key",
+	}
+)
+
+// Redacts the values of sensitive keys, also within maps and headers.
+func redact(key string, value interface{}) interface{} {
+	if isSensitive(key) {
+		return redacted
+	}
+
+	switch value := value.(type) {
+	case Fields:
+		return redactMap(value)
+	case map[string]interface{}:
+		return redactMap(value)
+	case map[string]string:
+		redactedMap := make(map[string]string, len(value))
+		for key, value := range value {
+			if isSensitive(key) {
+				value = redacted
+			}
+
+			redactedMap[key] = value
+		}
+
+		return redactedMap
+	case http.Header:
+		redactedHeader := make(http.Header, len(value))
+		for key, values := range value {
+			if isSensitive(key) {
+				values = []string{redacted}
+			}
+
+			redactedHeader[key] = values
+		}
+
+		return redactedHeader
+	}
+
+	return value
+}
+
+func redactMap(values map[string]interface{}) map[string]interface{} {
+	redactedMap := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		redactedMap[key] = redact(key, value)
+	}
+
+	return redactedMap
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitiveKey := range sensitiveKeys {
+		if strings.Contains(key, sensitiveKey) {
+			return true
+		}
+	}
+
+	return false
+}
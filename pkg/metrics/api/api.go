@@ -0,0 +1,53 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"survey-api/pkg/apperror"
+	"survey-api/pkg/config"
+	"survey-api/pkg/di"
+	"survey-api/pkg/metrics"
+	"survey-api/pkg/router"
+)
+
+const (
+	bearerPrefix = "Bearer "
+)
+
+var (
+	ErrInvalidMetricsToken = apperror.Unauthorized("Invalid metrics token")
+)
+
+func Routes(rt *router.Router, container *di.Dependencies) {
+	Init(rt, container.Config, container.Metrics)
+}
+
+// Init serves the metrics of the process. It is only deployed with the
+// standalone server, which serves the metrics of every route, see the
+// metrics package.
+func Init(rt *router.Router, config *config.Config, metrics *metrics.Service) {
+	rt.Handle(http.MethodGet, "/metrics", func(w http.ResponseWriter, r *http.Request) error {
+		if !authorized(r, config.Metrics.Token) {
+			return ErrInvalidMetricsToken
+		}
+
+		metrics.Handler().ServeHTTP(w, r)
+		return nil
+	})
+}
+
+// Without a configured token, the metrics are public.
+func authorized(r *http.Request, token string) bool {
+	if len(token) == 0 {
+		return true
+	}
+
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return false
+	}
+
+	given := strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
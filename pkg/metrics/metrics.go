@@ -0,0 +1,162 @@
+// Package metrics collects the Prometheus metrics of the application, which
+// are served by the /metrics endpoint.
+//
+// The metrics are only complete in the standalone server, which serves
+// every route from one process. Every serverless function is a process of
+// its own, which keeps its metrics to itself, so /metrics is not deployed
+// as a serverless function. Scrape the standalone server instead.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"survey-api/pkg/storage"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "survey"
+
+	LoginSucceeded = "succeeded"
+	LoginFailed    = "failed"
+)
+
+var (
+	// From 5ms to 10s, requests wait on the storage and on password hashing.
+	durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+)
+
+type Service struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	storageDuration *prometheus.HistogramVec
+	registrations   prometheus.Counter
+	logins          *prometheus.CounterVec
+	pollsCreated    prometheus.Counter
+	votesCast       prometheus.Counter
+	refreshes       prometheus.Counter
+}
+
+// New creates the metrics in a registry of their own, with the metrics of
+// the Go runtime and of the process.
+func New() *Service {
+	s := &Service{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route pattern and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of the HTTP requests by method, route pattern and status.",
+			Buckets:   durationBuckets,
+		}, []string{"method", "route", "status"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_duration_seconds",
+			Help:      "Duration of the repository operations by storage, repository, method and outcome.",
+			Buckets:   durationBuckets,
+		}, []string{"storage", "repo", "method", "outcome"}),
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "registrations_total",
+			Help:      "Accepted registrations.",
+		}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Logins by result, succeeded or failed.",
+		}, []string{"result"}),
+		pollsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "polls_created_total",
+			Help:      "Created polls.",
+		}),
+		votesCast: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "votes_cast_total",
+			Help:      "Votes cast.",
+		}),
+		refreshes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "token_refreshes_total",
+			Help:      "Refreshed sessions.",
+		}),
+	}
+	s.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		s.requests,
+		s.requestDuration,
+		s.storageDuration,
+		s.registrations,
+		s.logins,
+		s.pollsCreated,
+		s.votesCast,
+		s.refreshes,
+	)
+	return s
+}
+
+// Handler serves the metrics in the text format of Prometheus.
+func (s *Service) Handler() http.Handler {
+	return promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest counts a request. The route is the pattern of the route,
+// never the path, so that the number of series stays bounded.
+func (s *Service) ObserveRequest(method string, route string, status int, duration time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	s.requests.WithLabelValues(method, route, statusLabel).Inc()
+	s.requestDuration.WithLabelValues(method, route, statusLabel).Observe(duration.Seconds())
+}
+
+// StorageObserver returns the function, which an instrumented repository
+// calls after every operation.
+func (s *Service) StorageObserver(storageName string, repo string) func(method string, start time.Time, err error) {
+	return func(method string, start time.Time, err error) {
+		s.storageDuration.WithLabelValues(storageName, repo, method, outcome(err)).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (s *Service) Registered() {
+	s.registrations.Inc()
+}
+
+// LoggedIn counts a login by its result, LoginSucceeded or LoginFailed.
+func (s *Service) LoggedIn(result string) {
+	s.logins.WithLabelValues(result).Inc()
+}
+
+func (s *Service) PollCreated() {
+	s.pollsCreated.Inc()
+}
+
+func (s *Service) VoteCast() {
+	s.votesCast.Inc()
+}
+
+func (s *Service) Refreshed() {
+	s.refreshes.Inc()
+}
+
+// Expected errors are told apart from failures of the storage.
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, storage.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, storage.ErrDuplicate):
+		return "duplicate"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"survey-api/pkg/config"
+	"survey-api/pkg/router"
+)
+
+const (
+	forwardedForHeader = "X-Forwarded-For"
+	realIpHeader       = "X-Real-IP"
+)
+
+// ClientIp takes the address of the client from the headers of a trusted
+// proxy, for router.ClientIp. Every proxy appends its peer to
+// X-Forwarded-For, so the client is the last address, which is not a
+// trusted proxy. The addresses before it may be forged by the client.
+// X-Real-IP is only used, when X-Forwarded-For names no other address.
+// The requests of other peers keep the peer as the client.
+func ClientIp(c *config.Server) router.Middleware {
+	proxies := c.Proxies()
+	return func(next http.Handler) http.Handler {
+		if len(proxies) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !trusted(proxies, net.ParseIP(router.PeerIp(r))) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ip := forwardedIp(r, proxies)
+			if len(ip) != 0 {
+				r = router.WithClientIp(r, ip)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedIp(r *http.Request, proxies []*net.IPNet) string {
+	// Repeated headers are one list, in their order.
+	var addresses []string
+	for _, value := range r.Header.Values(forwardedForHeader) {
+		addresses = append(addresses, strings.Split(value, ",")...)
+	}
+
+	for i := len(addresses) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(addresses[i]))
+		if ip == nil {
+			// Nothing before a malformed address can be trusted.
+			return ""
+		}
+
+		if !trusted(proxies, ip) {
+			return ip.String()
+		}
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(r.Header.Get(realIpHeader)))
+	if ip == nil {
+		return ""
+	}
+
+	return ip.String()
+}
+
+func trusted(proxies []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"survey-api/pkg/config"
+	"survey-api/pkg/router"
+	"testing"
+)
+
+func TestClientIp(t *testing.T) {
+	proxies := &config.Server{TrustedProxies: "10.0.0.0/8, 192.0.2.1, 2001:db8::1"}
+	tests := []struct {
+		name         string
+		server       *config.Server
+		remoteAddr   string
+		forwardedFor []string
+		realIp       string
+		expected     string
+	}{
+		{"no trusted proxy", &config.Server{}, "192.0.2.1:1234", []string{"198.51.100.7"}, "", "192.0.2.1"},
+		{"untrusted peer", proxies, "203.0.113.9:1234", []string{"198.51.100.7"}, "198.51.100.8", "203.0.113.9"},
+		{"trusted peer", proxies, "192.0.2.1:1234", []string{"198.51.100.7"}, "", "198.51.100.7"},
+		{"chain of proxies", proxies, "10.0.0.2:1234", []string{"198.51.100.7, 10.0.0.3"}, "", "198.51.100.7"},
+		{"forged address", proxies, "10.0.0.2:1234", []string{"203.0.113.1, 198.51.100.7"}, "", "198.51.100.7"},
+		{"repeated header", proxies, "10.0.0.2:1234", []string{"198.51.100.7", "10.0.0.3"}, "", "198.51.100.7"},
+		{"malformed address", proxies, "10.0.0.2:1234", []string{"198.51.100.7, unknown"}, "198.51.100.8", "10.0.0.2"},
+		{"real IP", proxies, "10.0.0.2:1234", nil, "198.51.100.8", "198.51.100.8"},
+		{"real IP behind proxies", proxies, "10.0.0.2:1234", []string{"10.0.0.3"}, "198.51.100.8", "198.51.100.8"},
+		{"no header", proxies, "10.0.0.2:1234", nil, "", "10.0.0.2"},
+		{"IPv6 proxy", proxies, "[2001:db8::1]:1234", []string{"2001:db8::7"}, "", "2001:db8::7"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ip string
+			handler := ClientIp(test.server)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ip = router.ClientIp(r)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = test.remoteAddr
+			for _, value := range test.forwardedFor {
+				r.Header.Add(forwardedForHeader, value)
+			}
+
+			if len(test.realIp) != 0 {
+				r.Header.Set(realIpHeader, test.realIp)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			if ip != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, ip)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"survey-api/pkg/config"
+	"survey-api/pkg/requestid"
+	"survey-api/pkg/router"
+)
+
+var (
+	corsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}
+	corsHeaders = []string{"Authorization", "Content-Type", requestid.Header}
+	// The headers of the responses, which the clients may read, besides
+	// the CORS-safelisted ones.
+	corsExposedHeaders = []string{
+		requestid.Header,
+		"X-RateLimit-Limit",
+		"X-RateLimit-Remaining",
+		"X-RateLimit-Reset",
+		"Retry-After",
+	}
+)
+
+// Cors lets the browsers of the allowed origins call the API. Preflight
+// requests are answered right away, whether their origin is allowed or
+// not, since they match no route. Without any allowed origin, it does
+// nothing.
+func Cors(c *config.Cors) router.Middleware {
+	origins := c.Origins()
+	anyOrigin := false
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		anyOrigin = anyOrigin || origin == "*"
+		allowed[origin] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(origins) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			origin := r.Header.Get("Origin")
+			allowOrigin := "*"
+			if !anyOrigin {
+				// Caches must not serve the response of one origin to another.
+				header.Add("Vary", "Origin")
+				allowOrigin = origin
+			}
+
+			preflight := r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) != 0
+			if len(origin) == 0 || (!anyOrigin && !allowed[origin]) {
+				serveCors(w, r, next, preflight)
+				return
+			}
+
+			header.Set("Access-Control-Allow-Origin", allowOrigin)
+			if c.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if preflight {
+				header.Set("Access-Control-Allow-Methods", strings.Join(corsMethods, ", "))
+				header.Set("Access-Control-Allow-Headers", strings.Join(corsHeaders, ", "))
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+			} else {
+				header.Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+			}
+
+			serveCors(w, r, next, preflight)
+		})
+	}
+}
+
+func serveCors(w http.ResponseWriter, r *http.Request, next http.Handler, preflight bool) {
+	if preflight {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	next.ServeHTTP(w, r)
+}
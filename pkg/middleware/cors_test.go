@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"survey-api/pkg/config"
+	"testing"
+	"time"
+)
+
+func TestCors(t *testing.T) {
+	origins := &config.Cors{
+		AllowedOrigins:   "https://survey.example.com, https://admin.example.com",
+		AllowCredentials: true,
+		MaxAge:           time.Minute,
+	}
+	tests := []struct {
+		name        string
+		cors        *config.Cors
+		method      string
+		origin      string
+		status      int
+		allowOrigin string
+		maxAge      string
+	}{
+		{"allowed origin", origins, http.MethodGet, "https://admin.example.com", http.StatusOK, "https://admin.example.com", ""},
+		{"preflight", origins, http.MethodOptions, "https://survey.example.com", http.StatusNoContent, "https://survey.example.com", "60"},
+		{"other origin", origins, http.MethodGet, "https://evil.example.com", http.StatusOK, "", ""},
+		{"preflight of other origin", origins, http.MethodOptions, "https://evil.example.com", http.StatusNoContent, "", ""},
+		{"same origin", origins, http.MethodGet, "", http.StatusOK, "", ""},
+		{"any origin", &config.Cors{AllowedOrigins: "*"}, http.MethodGet, "https://evil.example.com", http.StatusOK, "*", ""},
+		{"disabled", &config.Cors{}, http.MethodOptions, "https://survey.example.com", http.StatusTeapot, "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := Cors(test.cors)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodOptions {
+					w.WriteHeader(http.StatusTeapot)
+				}
+			}))
+
+			r := httptest.NewRequest(test.method, "/poll/42", nil)
+			if len(test.origin) != 0 {
+				r.Header.Set("Origin", test.origin)
+			}
+
+			if test.method == http.MethodOptions {
+				r.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.status {
+				t.Fatalf("expected status %d, got %d", test.status, w.Code)
+			}
+
+			header := w.Header()
+			if header.Get("Access-Control-Allow-Origin") != test.allowOrigin {
+				t.Errorf("expected the allowed origin %q, got %q", test.allowOrigin, header.Get("Access-Control-Allow-Origin"))
+			}
+
+			if header.Get("Access-Control-Max-Age") != test.maxAge {
+				t.Errorf("expected the max age %q, got %q", test.maxAge, header.Get("Access-Control-Max-Age"))
+			}
+
+			credentials := len(test.allowOrigin) != 0 && test.cors.AllowCredentials
+			if (header.Get("Access-Control-Allow-Credentials") == "true") != credentials {
+				t.Errorf("expected credentials to be allowed: %t", credentials)
+			}
+
+			varies := test.cors == origins
+			if (header.Get("Vary") == "Origin") != varies {
+				t.Errorf("expected the response to vary by origin: %t", varies)
+			}
+		})
+	}
+}
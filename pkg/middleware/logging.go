@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"survey-api/pkg/logger"
+	"survey-api/pkg/requestid"
+	"survey-api/pkg/router"
+	"time"
+)
+
+// statusRecorder keeps the status and the size of a response for the
+// access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(body []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	written, err := r.ResponseWriter.Write(body)
+	r.bytes += written
+	return written, err
+}
+
+// AccessLog gives every request a child logger with the id, the method and
+// the path of the request, and logs every response with its status, size
+// and latency. The query is left out, because it may carry secrets. The id
+// is the one of the RequestId middleware, which must run first.
+func AccessLog(log *logger.Service) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ctx := log.NewContext(r.Context(), logger.Fields{
+				"request_id": requestid.FromContext(r.Context()),
+				"method":     r.Method,
+				"path":       r.URL.Path,
+			})
+			recorder := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+
+			fields := logger.Fields{
+				"status":     recorder.status,
+				"bytes":      recorder.bytes,
+				"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+				"remote_ip":  router.ClientIp(r),
+				"user_agent": r.UserAgent(),
+			}
+			requestLog := log.For(ctx)
+			if recorder.status >= http.StatusInternalServerError {
+				requestLog.Error("Request served", fields)
+				return
+			}
+
+			requestLog.Info("Request served", fields)
+		})
+	}
+}
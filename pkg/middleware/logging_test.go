@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"survey-api/pkg/logger"
+	"survey-api/pkg/requestid"
+	"survey-api/pkg/router"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buffer bytes.Buffer
+	log := logger.NewWriter(&buffer, logger.LevelInfo)
+	handler := router.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.AddFields(r.Context(), logger.Fields{"user_id": "user"})
+		log.For(r.Context()).Info("Handled")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}), RequestId(), AccessLog(log))
+
+	r := httptest.NewRequest(http.MethodPost, "/poll?token=secret", nil)
+	r.Header.Set(requestid.Header, "client-id")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %s", buffer.String())
+	}
+
+	var handled, served map[string]interface{}
+	err := json.Unmarshal([]byte(lines[0]), &handled)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = json.Unmarshal([]byte(lines[1]), &served)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if handled["request_id"] != "client-id" || served["request_id"] != "client-id" {
+		t.Fatalf("expected the request id of the client on every line, got %v and %v", handled, served)
+	}
+
+	expected := map[string]interface{}{
+		"msg":     "Request served",
+		"method":  http.MethodPost,
+		"path":    "/poll",
+		"user_id": "user",
+		"status":  float64(http.StatusCreated),
+		"bytes":   float64(len("created")),
+	}
+	for key, value := range expected {
+		if served[key] != value {
+			t.Errorf("%s: expected %v, got %v", key, value, served[key])
+		}
+	}
+
+	if strings.Contains(buffer.String(), "secret") {
+		t.Errorf("expected the query to be left out, got %s", buffer.String())
+	}
+}
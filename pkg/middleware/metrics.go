@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"survey-api/pkg/metrics"
+	"survey-api/pkg/router"
+	"time"
+)
+
+const (
+	// Requests, which match no route, share a single series, whatever
+	// their path and method.
+	unmatchedRoute  = "unmatched"
+	unmatchedMethod = "other"
+)
+
+// Metrics counts every request and measures its latency by the pattern of
+// the matched route.
+func Metrics(metrics *metrics.Service) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+
+			method := r.Method
+			route, ok := router.MatchedRoute(r)
+			if !ok {
+				method = unmatchedMethod
+				route.Pattern = unmatchedRoute
+			}
+
+			metrics.ObserveRequest(method, route.Pattern, recorder.status, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"survey-api/pkg/apperror"
+	"survey-api/pkg/router"
+)
+
+// MaxBodySize rejects request bodies larger than limit bytes. The body is
+// only cut off while it is read, so apperror.ErrBodyTooLarge surfaces from
+// the decoding.
+func MaxBodySize(limit int64) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				apperror.Write(w, r, apperror.ErrBodyTooLarge)
+				return
+			}
+
+			r.Body = &limitedBody{ReadCloser: r.Body, remaining: limit}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitedBody is http.MaxBytesReader, with an error which can be told
+// apart from the other read errors.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, apperror.ErrBodyTooLarge
+	}
+
+	// One more byte than allowed tells whether the body is too large.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = -1
+		return n, apperror.ErrBodyTooLarge
+	}
+
+	b.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"survey-api/pkg/router"
+	"testing"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	rt := router.New(nil)
+	rt.Use(MaxBodySize(16))
+	rt.Handle(http.MethodPost, "/poll", func(w http.ResponseWriter, r *http.Request) error {
+		var body map[string]string
+		err := router.DecodeJSON(r, &body)
+		if err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"within the limit", `{"a": "b"}`, http.StatusNoContent},
+		{"too large", `{"content": "Tabs or spaces?"}`, http.StatusRequestEntityTooLarge},
+		{"malformed", `{"a": `, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/poll", strings.NewReader(test.body))
+		// Without a length, the body is only cut off while it is read.
+		r.ContentLength = -1
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, r)
+		if w.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"survey-api/pkg/apperror"
+	"survey-api/pkg/logger"
+	"survey-api/pkg/ratelimit"
+	"survey-api/pkg/router"
+	"time"
+)
+
+// RateLimit rejects the requests of a client with 429, once it exceeded
+// the limit of the matched route. Clients are the users of a valid token,
+// as returned by authToken, or else their addresses, see ClientIp. The
+// limiter failing lets the request through, so that a failed store does
+// not take down the routes with it.
+func RateLimit(log *logger.Service, limiter *ratelimit.Service, authToken func(*http.Request) (string, error)) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, ok := router.MatchedRoute(r)
+			if !ok || !limiter.Limits(route) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client := "ip:" + router.ClientIp(r)
+			userId, err := authToken(r)
+			if err == nil {
+				client = "user:" + userId
+			}
+
+			result, err := limiter.Take(r.Context(), route, client)
+			if err != nil {
+				log.For(r.Context()).Error("Failed to limit the request rate", logger.Fields{"error": err})
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+				apperror.Write(w, r, apperror.ErrTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// seconds rounds up, so that clients do not retry too early.
+func seconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+	"survey-api/pkg/requestid"
+	"survey-api/pkg/router"
+)
+
+// RequestId keeps the X-Request-ID header of the request, or generates one
+// when it is missing or invalid, and echoes it in the response.
+func RequestId() router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestid.Header)
+			if !requestid.Valid(id) {
+				id = requestid.New()
+			}
+
+			w.Header().Set(requestid.Header, id)
+			next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+		})
+	}
+}
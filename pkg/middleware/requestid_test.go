@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"survey-api/pkg/requestid"
+	"testing"
+)
+
+func TestRequestId(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"kept", "3f2a-b7c1_9.x:1", "3f2a-b7c1_9.x:1"},
+		{"missing", "", ""},
+		{"invalid", "id\nwith a newline", ""},
+		{"too long", strings.Repeat("a", 129), ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var inContext string
+			handler := RequestId()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				inContext = requestid.FromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+			if len(test.header) != 0 {
+				r.Header.Set(requestid.Header, test.header)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			echoed := w.Header().Get(requestid.Header)
+			if echoed != inContext || !requestid.Valid(echoed) {
+				t.Fatalf("expected the id of the context to be echoed, got %q and %q", inContext, echoed)
+			}
+
+			if len(test.expected) != 0 && echoed != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, echoed)
+			}
+
+			if len(test.expected) == 0 && echoed == test.header {
+				t.Errorf("expected a generated id, got %q", echoed)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"survey-api/pkg/config"
+	"survey-api/pkg/router"
+)
+
+const (
+	// The API only serves JSON, so any HTML, like a problem rendered by a
+	// browser, may not load or embed anything.
+	contentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+)
+
+// SecurityHeaders sets the security headers of every response.
+// Strict-Transport-Security is left out when HSTS_MAX_AGE is zero.
+func SecurityHeaders(c *config.Security) router.Middleware {
+	hsts := "max-age=" + strconv.Itoa(int(c.HstsMaxAge.Seconds()))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+			header.Set("Content-Security-Policy", contentSecurityPolicy)
+			header.Set("X-Frame-Options", "DENY")
+			header.Set("Referrer-Policy", "no-referrer")
+			if c.HstsMaxAge > 0 {
+				header.Set("Strict-Transport-Security", hsts)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"survey-api/pkg/apperror"
+	"survey-api/pkg/router"
+	"survey-api/pkg/storage"
+)
+
+// WaitForStorage holds the requests back until the gate is ready. While
+// the storage cannot be reached, they fail with 503, and the next request
+// tries again. The routes without storage, and requests matching no route,
+// are served right away.
+func WaitForStorage(gate *storage.Gate, withoutStorage []router.Route) router.Middleware {
+	skip := make(map[router.Route]bool, len(withoutStorage))
+	for _, route := range withoutStorage {
+		skip[route] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, ok := router.MatchedRoute(r)
+			if !ok || skip[route] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			err := gate.Wait(r.Context())
+			if err != nil {
+				apperror.Write(w, r, apperror.From(err))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
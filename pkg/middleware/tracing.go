@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"survey-api/pkg/logger"
+	"survey-api/pkg/requestid"
+	"survey-api/pkg/router"
+	"survey-api/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+// Tracing starts the span of every request, which continues the trace of
+// the traceparent header. The span is renamed after the matched route once
+// the request is served, and its trace id is logged with the request.
+func Tracing(tracingService *tracing.Service) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := tracingService.StartServer(r, "HTTP "+r.Method,
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPTargetKey.String(r.URL.Path),
+				attribute.String("http.request_id", requestid.FromContext(r.Context())),
+			)
+			defer span.End()
+
+			traceId := tracing.TraceId(ctx)
+			if len(traceId) != 0 {
+				logger.AddFields(ctx, logger.Fields{"trace_id": traceId})
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w}
+			r = r.WithContext(ctx)
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+
+			route, ok := router.MatchedRoute(r)
+			if ok {
+				span.SetName("HTTP " + r.Method + " " + route.Pattern)
+				span.SetAttributes(semconv.HTTPRouteKey.String(route.Pattern))
+			}
+
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(recorder.status))
+			if recorder.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(recorder.status))
+			}
+		})
+	}
+}
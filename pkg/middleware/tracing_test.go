@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"survey-api/pkg/router"
+	"survey-api/pkg/tracing"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracingService := tracing.NewWithProcessor(sdktrace.NewSimpleSpanProcessor(exporter))
+	rt := router.New(nil)
+	rt.Use(Tracing(tracingService))
+	rt.Handle(http.MethodDelete, "/poll/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		_, span := tracingService.Start(r.Context(), "pollhandler.DeletePoll")
+		span.End()
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+
+	r := httptest.NewRequest(http.MethodDelete, "/poll/42", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rt.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	child, server := spans[0], spans[1]
+	if server.Name != "HTTP DELETE /poll/{id}" || server.SpanKind != trace.SpanKindServer {
+		t.Errorf("expected the server span of the route, got %s", server.Name)
+	}
+
+	if server.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the trace of the traceparent header, got %s", server.SpanContext.TraceID())
+	}
+
+	if server.Parent.SpanID().String() != "00f067aa0ba902b7" || !server.Parent.IsRemote() {
+		t.Errorf("expected the remote parent of the traceparent header, got %s", server.Parent.SpanID())
+	}
+
+	if child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("expected the handler span to be a child of the server span")
+	}
+
+	for _, attribute := range server.Attributes {
+		if attribute.Key == "http.status_code" && attribute.Value.AsInt64() != http.StatusNoContent {
+			t.Errorf("expected status %d, got %d", http.StatusNoContent, attribute.Value.AsInt64())
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	ErrUnknownVersion = errors.New("Unknown migration version")
+	ErrPending        = errors.New("Migrations are pending, run: go run ./cmd/migrate up")
+)
+
+// Migration is a single, versioned change of the schema. Once released,
+// a migration must never change, add a new one instead.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context) error
+	Down    func(ctx context.Context) error
+}
+
+// Store keeps track of the applied migrations, in the same database as
+// the data they change.
+type Store interface {
+	Applied(ctx context.Context) (map[int]time.Time, error)
+	Insert(ctx context.Context, migration *Migration, appliedAt time.Time) error
+	Delete(ctx context.Context, migration *Migration) error
+}
+
+// Status of a migration, with a nil AppliedAt while it is pending.
+type Status struct {
+	Migration *Migration
+	AppliedAt *time.Time
+}
+
+type Service struct {
+	store      Store
+	migrations []*Migration
+}
+
+func New(store Store, migrations []*Migration) *Service {
+	return &Service{store: store, migrations: migrations}
+}
+
+func (s *Service) Status(ctx context.Context) ([]*Status, error) {
+	applied, err := s.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*Status, 0, len(s.migrations))
+	for _, migration := range s.migrations {
+		status := &Status{Migration: migration}
+		appliedAt, ok := applied[migration.Version]
+		if ok {
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (s *Service) Pending(ctx context.Context) ([]*Migration, error) {
+	applied, err := s.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return planUp(s.migrations, applied, 0), nil
+}
+
+// Check reports pending migrations with ErrPending, so that the application
+// does not run without the indexes and tables, which it expects.
+func (s *Service) Check(ctx context.Context) error {
+	pending, err := s.Pending(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) != 0 {
+		return ErrPending
+	}
+
+	return nil
+}
+
+// Up applies the pending migrations up to and including the target
+// version, or all of them for a target of 0. With dryRun, the migrations
+// are only returned. On failure, the migrations applied so far are
+// returned with the error.
+func (s *Service) Up(ctx context.Context, target int, dryRun bool) ([]*Migration, error) {
+	if target != 0 && s.find(target) == nil {
+		return nil, ErrUnknownVersion
+	}
+
+	applied, err := s.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := planUp(s.migrations, applied, target)
+	if dryRun {
+		return plan, nil
+	}
+
+	for i, migration := range plan {
+		err = migration.Up(ctx)
+		if err != nil {
+			return plan[:i], err
+		}
+
+		err = s.store.Insert(ctx, migration, time.Now().UTC())
+		if err != nil {
+			return plan[:i], err
+		}
+	}
+
+	return plan, nil
+}
+
+// Down reverts the applied migrations above the target version, the
+// latest first. A target of 0 reverts every migration.
+func (s *Service) Down(ctx context.Context, target int, dryRun bool) ([]*Migration, error) {
+	if target != 0 && s.find(target) == nil {
+		return nil, ErrUnknownVersion
+	}
+
+	applied, err := s.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := planDown(s.migrations, applied, target)
+	if dryRun {
+		return plan, nil
+	}
+
+	for i, migration := range plan {
+		err = migration.Down(ctx)
+		if err != nil {
+			return plan[:i], err
+		}
+
+		err = s.store.Delete(ctx, migration)
+		if err != nil {
+			return plan[:i], err
+		}
+	}
+
+	return plan, nil
+}
+
+func (s *Service) find(version int) *Migration {
+	for _, migration := range s.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+
+	return nil
+}
+
+func planUp(migrations []*Migration, applied map[int]time.Time, target int) []*Migration {
+	var plan []*Migration
+	for _, migration := range migrations {
+		if target != 0 && migration.Version > target {
+			break
+		}
+
+		_, ok := applied[migration.Version]
+		if !ok {
+			plan = append(plan, migration)
+		}
+	}
+
+	return plan
+}
+
+func planDown(migrations []*Migration, applied map[int]time.Time, target int) []*Migration {
+	var plan []*Migration
+	for _, migration := range migrations {
+		_, ok := applied[migration.Version]
+		if ok && migration.Version > target {
+			plan = append(plan, migration)
+		}
+	}
+
+	sort.Slice(plan, func(i, j int) bool {
+		return plan[i].Version > plan[j].Version
+	})
+	return plan
+}
@@ -0,0 +1,133 @@
+package migration
+
+import (
+	"context"
+	"strings"
+	"survey-api/pkg/user/model"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	tests := map[string][]*Migration{
+		"mongodb":  mongodbMigrations(nil),
+		"postgres": postgresMigrations(nil),
+	}
+	for storage, migrations := range tests {
+		names := make(map[string]bool)
+		for i, migration := range migrations {
+			if migration.Version != i+1 {
+				t.Errorf("%s: expected version %d for %s, got %d", storage, i+1, migration.Name, migration.Version)
+			}
+
+			if len(migration.Name) == 0 || names[migration.Name] {
+				t.Errorf("%s: expected a unique name for version %d", storage, migration.Version)
+			}
+
+			if migration.Up == nil || migration.Down == nil {
+				t.Errorf("%s: expected %s to be reversible", storage, migration.Name)
+			}
+
+			names[migration.Name] = true
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	migrations := []*Migration{{Version: 1}, {Version: 2}, {Version: 3}, {Version: 4}}
+	applied := map[int]time.Time{1: time.Now(), 3: time.Now()}
+	tests := []struct {
+		name     string
+		plan     []*Migration
+		expected []int
+	}{
+		{"up to latest", planUp(migrations, applied, 0), []int{2, 4}},
+		{"up to target", planUp(migrations, applied, 2), []int{2}},
+		{"up to applied target", planUp(migrations, applied, 1), nil},
+		{"down to nothing", planDown(migrations, applied, 0), []int{3, 1}},
+		{"down to target", planDown(migrations, applied, 2), []int{3}},
+		{"down to latest", planDown(migrations, applied, 3), nil},
+	}
+
+	for _, test := range tests {
+		var versions []int
+		for _, migration := range test.plan {
+			versions = append(versions, migration.Version)
+		}
+
+		if len(versions) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, versions)
+			continue
+		}
+
+		for i := range versions {
+			if versions[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got %v", test.name, test.expected, versions)
+				break
+			}
+		}
+	}
+}
+
+type memoryStore struct {
+	applied map[int]time.Time
+}
+
+func (s *memoryStore) Applied(ctx context.Context) (map[int]time.Time, error) {
+	return s.applied, nil
+}
+
+func (s *memoryStore) Insert(ctx context.Context, migration *Migration, appliedAt time.Time) error {
+	s.applied[migration.Version] = appliedAt
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, migration *Migration) error {
+	delete(s.applied, migration.Version)
+	return nil
+}
+
+func TestCheck(t *testing.T) {
+	noop := func(ctx context.Context) error {
+		return nil
+	}
+	migrations := []*Migration{{Version: 1, Up: noop, Down: noop}, {Version: 2, Up: noop, Down: noop}}
+	service := New(&memoryStore{applied: map[int]time.Time{1: time.Now()}}, migrations)
+	err := service.Check(context.Background())
+	if err != ErrPending {
+		t.Fatalf("expected %v, got %v", ErrPending, err)
+	}
+
+	_, err = service.Up(context.Background(), 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.Check(context.Background())
+	if err != nil {
+		t.Errorf("expected no pending migration, got %v", err)
+	}
+}
+
+func TestPlanNormalization(t *testing.T) {
+	alice := model.User{Id: primitive.NewObjectID(), UserName: "alice", Email: "alice@example.com"}
+	bob := model.User{Id: primitive.NewObjectID(), UserName: "Bob", Email: "bob@example.com"}
+	updates, conflicts := planNormalization([]model.User{alice, bob})
+	if len(updates) != 1 || len(conflicts) != 0 {
+		t.Fatalf("expected an update of Bob, got %d updates and %v", len(updates), conflicts)
+	}
+
+	tests := map[string]model.User{
+		"user name": {Id: primitive.NewObjectID(), UserName: "ALICE", Email: "other@example.com"},
+		"email":     {Id: primitive.NewObjectID(), UserName: "carol", Email: "Alice@Example.com"},
+		"@":         {Id: primitive.NewObjectID(), UserName: "carol@home", Email: "carol@example.com"},
+	}
+	for name, user := range tests {
+		_, conflicts = planNormalization([]model.User{alice, user})
+		if len(conflicts) != 1 || !strings.Contains(conflicts[0], user.Id.Hex()) {
+			t.Errorf("%s: expected a conflict of %s, got %v", name, user.Id.Hex(), conflicts)
+		}
+	}
+}
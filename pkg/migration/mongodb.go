@@ -0,0 +1,168 @@
+package migration
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	mongodbCollectionName = "migrations"
+	indexNotFoundCode     = 27
+)
+
+type mongodbRecord struct {
+	Version   int                `bson:"_id"`
+	Name      string             `bson:"name"`
+	AppliedAt primitive.DateTime `bson:"applied_at"`
+}
+
+type mongodbStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongodb returns the migrations of a MongoDB database.
+func NewMongodb(db *mongo.Database) *Service {
+	return New(&mongodbStore{collection: db.Collection(mongodbCollectionName)}, mongodbMigrations(db))
+}
+
+func (s *mongodbStore) Applied(ctx context.Context) (map[int]time.Time, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+	applied := make(map[int]time.Time)
+	for cursor.Next(ctx) {
+		var record mongodbRecord
+		err = cursor.Decode(&record)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[record.Version] = record.AppliedAt.Time().UTC()
+	}
+
+	return applied, cursor.Err()
+}
+
+func (s *mongodbStore) Insert(ctx context.Context, migration *Migration, appliedAt time.Time) error {
+	_, err := s.collection.InsertOne(ctx, &mongodbRecord{
+		Version:   migration.Version,
+		Name:      migration.Name,
+		AppliedAt: primitive.NewDateTimeFromTime(appliedAt),
+	})
+	return err
+}
+
+func (s *mongodbStore) Delete(ctx context.Context, migration *Migration) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": migration.Version})
+	return err
+}
+
+// The migrations of a MongoDB database in the order of their versions. The
+// first ones create the indexes, which used to be created by the
+// repositories, so they leave existing databases unchanged.
+func mongodbMigrations(db *mongo.Database) []*Migration {
+	return []*Migration{
+		{
+			Version: 1,
+			Name:    "create_session_indexes",
+			Up: func(ctx context.Context) error {
+				sessionValiditySeconds := int32((12 * time.Hour).Seconds())
+				_, err := db.Collection("session").Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys:    bson.M{"last_modified": 1},
+						Options: options.Index().SetExpireAfterSeconds(sessionValiditySeconds),
+					},
+					{
+						Keys: bson.M{"token": "text"},
+					},
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return dropIndexes(ctx, db.Collection("session"), "last_modified_1", "token_text")
+			},
+		},
+		{
+			Version: 2,
+			Name:    "create_poll_indexes",
+			Up: func(ctx context.Context) error {
+				_, err := db.Collection("poll").Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys: bson.M{"content": "text"},
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return dropIndexes(ctx, db.Collection("poll"), "content_text")
+			},
+		},
+		{
+			Version: 3,
+			Name:    "normalize_users",
+			Up: func(ctx context.Context) error {
+				return normalizeUsersUp(ctx, db)
+			},
+			Down: func(ctx context.Context) error {
+				return normalizeUsersDown(ctx, db)
+			},
+		},
+		{
+			Version: 4,
+			Name:    "create_audit_indexes",
+			Up: func(ctx context.Context) error {
+				_, err := db.Collection("audit").Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys: bson.D{{Key: "time", Value: -1}, {Key: "_id", Value: -1}},
+					},
+					{
+						Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "time", Value: -1}},
+					},
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return dropIndexes(ctx, db.Collection("audit"), "time_-1__id_-1", "actor_id_1_time_-1")
+			},
+		},
+		{
+			Version: 5,
+			Name:    "create_rate_limit_indexes",
+			Up: func(ctx context.Context) error {
+				// Buckets expire once they are full again.
+				_, err := db.Collection("rate_limit").Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.M{"expires": 1},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return dropIndexes(ctx, db.Collection("rate_limit"), "expires_1")
+			},
+		},
+	}
+}
+
+// Dropping an index, which does not exist, is not an error, so that a
+// migration, which failed halfway, can be reverted.
+func dropIndexes(ctx context.Context, collection *mongo.Collection, names ...string) error {
+	for _, name := range names {
+		_, err := collection.Indexes().DropOne(ctx, name)
+		if err != nil && !isIndexNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isIndexNotFound(err error) bool {
+	commandError, ok := err.(mongo.CommandError)
+	return ok && commandError.Code == indexNotFoundCode
+}
@@ -0,0 +1,186 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	postgresTableName = "schema_migrations"
+)
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgres returns the migrations of a PostgreSQL database.
+func NewPostgres(db *sql.DB) *Service {
+	return New(&postgresStore{db: db}, postgresMigrations(db))
+}
+
+// Reading the applied migrations never writes, so that the status and the
+// check of the server leave the database as it is. The table is created by
+// the first migration applied.
+func (s *postgresStore) Applied(ctx context.Context) (map[int]time.Time, error) {
+	var table sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT to_regclass($1)::TEXT`, postgresTableName).Scan(&table)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]time.Time)
+	if !table.Valid {
+		return applied, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT version, applied_at FROM `+postgresTableName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt.UTC()
+	}
+
+	return applied, rows.Err()
+}
+
+func (s *postgresStore) Insert(ctx context.Context, migration *Migration, appliedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+postgresTableName+` (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO `+postgresTableName+` (version, name, applied_at) VALUES ($1, $2, $3)`,
+		migration.Version, migration.Name, appliedAt)
+	return err
+}
+
+func (s *postgresStore) Delete(ctx context.Context, migration *Migration) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM `+postgresTableName+` WHERE version = $1`, migration.Version)
+	return err
+}
+
+// The migrations of a PostgreSQL database in the order of their versions.
+// The ids are the hex strings of the ObjectIDs, which the application
+// generates, so that they look the same with every storage.
+func postgresMigrations(db *sql.DB) []*Migration {
+	return []*Migration{
+		{
+			Version: 1,
+			Name:    "create_tables",
+			Up: execStatements(db, `
+				CREATE TABLE users (
+					id CHAR(24) PRIMARY KEY,
+					first_name TEXT NOT NULL,
+					user_name TEXT NOT NULL,
+					email TEXT NOT NULL,
+					password TEXT NOT NULL,
+					avatar_url TEXT NOT NULL DEFAULT ''
+				);
+				CREATE UNIQUE INDEX users_user_name_key ON users (lower(user_name));
+				CREATE UNIQUE INDEX users_email_key ON users (lower(email));
+
+				CREATE TABLE sessions (
+					id CHAR(24) PRIMARY KEY,
+					user_id CHAR(24) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+					token TEXT NOT NULL,
+					last_modified TIMESTAMPTZ NOT NULL
+				);
+				CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+				CREATE INDEX sessions_token_idx ON sessions (token);
+
+				CREATE TABLE polls (
+					id CHAR(24) PRIMARY KEY,
+					owner_id CHAR(24) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+					content TEXT NOT NULL,
+					visibility TEXT NOT NULL,
+					created TIMESTAMPTZ,
+					closed TIMESTAMPTZ,
+					last_modified TIMESTAMPTZ NOT NULL
+				);
+				CREATE INDEX polls_owner_id_idx ON polls (owner_id);
+
+				CREATE TABLE poll_options (
+					poll_id CHAR(24) NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+					position INTEGER NOT NULL,
+					option_index TEXT NOT NULL,
+					content TEXT NOT NULL,
+					count INTEGER NOT NULL DEFAULT 0 CHECK (count >= 0),
+					PRIMARY KEY (poll_id, position)
+				);
+
+				CREATE TABLE votes (
+					poll_id CHAR(24) NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+					voter_id CHAR(24) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+					seq BIGSERIAL NOT NULL,
+					CONSTRAINT votes_poll_id_voter_id_key UNIQUE (poll_id, voter_id)
+				);
+				CREATE INDEX votes_voter_id_idx ON votes (voter_id);
+			`),
+			Down: execStatements(db, `
+				DROP TABLE IF EXISTS votes;
+				DROP TABLE IF EXISTS poll_options;
+				DROP TABLE IF EXISTS polls;
+				DROP TABLE IF EXISTS sessions;
+				DROP TABLE IF EXISTS users;
+			`),
+		},
+		{
+			Version: 2,
+			Name:    "create_audit_events",
+			// The actor has no foreign key, the events outlive the users.
+			Up: execStatements(db, `
+				CREATE TABLE audit_events (
+					id CHAR(24) PRIMARY KEY,
+					time TIMESTAMPTZ NOT NULL,
+					actor_id CHAR(24),
+					action TEXT NOT NULL,
+					target TEXT NOT NULL DEFAULT '',
+					ip TEXT NOT NULL DEFAULT '',
+					user_agent TEXT NOT NULL DEFAULT '',
+					outcome TEXT NOT NULL,
+					reason TEXT NOT NULL DEFAULT '',
+					request_id TEXT NOT NULL DEFAULT ''
+				);
+				CREATE INDEX audit_events_time_idx ON audit_events (time DESC, id DESC);
+				CREATE INDEX audit_events_actor_id_idx ON audit_events (actor_id, time DESC);
+			`),
+			Down: execStatements(db, `
+				DROP TABLE IF EXISTS audit_events;
+			`),
+		},
+	}
+}
+
+// Statements run in a transaction, so that a failed migration leaves
+// nothing behind.
+func execStatements(db *sql.DB, statements string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, statements)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		return tx.Commit()
+	}
+}
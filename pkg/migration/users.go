@@ -0,0 +1,140 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"survey-api/pkg/user/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// Must match the collation of the lookups in pkg/user/repo.
+	userCollation = &options.Collation{Locale: "en", Strength: 2}
+)
+
+// Users registered before names and emails were normalized are rewritten to
+// the canonical form, and the legacy indexes are replaced by
+// case-insensitive unique ones. Users, who collide after normalization,
+// or whose names contain an "@", are reported to be resolved manually.
+func normalizeUsersUp(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection("user")
+	err := normalizeUsers(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	err = dropIndexes(ctx, collection, "user_name_text", "email_1")
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.M{"user_name": 1},
+			Options: options.Index().
+				SetName("user_name_ci").
+				SetUnique(true).
+				SetCollation(userCollation),
+		}, {
+			Keys: bson.M{"email": 1},
+			Options: options.Index().
+				SetName("email_ci").
+				SetUnique(true).
+				SetCollation(userCollation),
+		},
+	})
+	return err
+}
+
+// The normalized names and emails are kept, only the legacy indexes are
+// restored.
+func normalizeUsersDown(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection("user")
+	err := dropIndexes(ctx, collection, "user_name_ci", "email_ci")
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.M{"user_name": "text"},
+			Options: options.Index().SetUnique(true),
+		}, {
+			Keys:    bson.M{"email": 1},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	return err
+}
+
+func normalizeUsers(ctx context.Context, collection *mongo.Collection) error {
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+	var users []model.User
+	err = cursor.All(ctx, &users)
+	if err != nil {
+		return err
+	}
+
+	updates, conflicts := planNormalization(users)
+	if len(conflicts) != 0 {
+		return errors.New("Users collide after normalization, resolve them manually: " + strings.Join(conflicts, "; "))
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	_, err = collection.BulkWrite(ctx, updates)
+	return err
+}
+
+// planNormalization returns the updates of the users, which are not in the
+// canonical form, and the conflicts, which prevent them. User names with an
+// "@" are conflicts too, since logins resolve them as emails, so the user
+// could never log in with the name.
+func planNormalization(users []model.User) ([]mongo.WriteModel, []string) {
+	userNames := make(map[string]primitive.ObjectID)
+	emails := make(map[string]primitive.ObjectID)
+	var conflicts []string
+	var updates []mongo.WriteModel
+	for _, user := range users {
+		userName := model.NormalizeUserName(user.UserName)
+		email := model.NormalizeEmail(user.Email)
+		if strings.Contains(userName, "@") {
+			conflicts = append(conflicts, "user_name "+userName+" contains an @ ("+user.Id.Hex()+")")
+		}
+
+		otherId, ok := userNames[userName]
+		if ok {
+			conflicts = append(conflicts, "user_name "+userName+" ("+otherId.Hex()+", "+user.Id.Hex()+")")
+		}
+
+		otherId, ok = emails[email]
+		if ok {
+			conflicts = append(conflicts, "email "+email+" ("+otherId.Hex()+", "+user.Id.Hex()+")")
+		}
+
+		userNames[userName] = user.Id
+		emails[email] = user.Id
+		if userName == user.UserName && email == user.Email {
+			continue
+		}
+
+		update := mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": user.Id}).
+			SetUpdate(bson.M{"$set": bson.M{"user_name": userName, "email": email}})
+		updates = append(updates, update)
+	}
+
+	return updates, conflicts
+}
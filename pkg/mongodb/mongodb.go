@@ -0,0 +1,225 @@
+package mongodb
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"survey-api/pkg/config"
+	"survey-api/pkg/storage"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const (
+	connectAttempts       = 3
+	initialConnectBackoff = 250 * time.Millisecond
+	defaultConnectTimeout = 2 * time.Second
+)
+
+var (
+	ErrInvalidCAFile = errors.New("No certificates found in the MongoDB CA file")
+)
+
+// NewClient connects to MongoDB and waits until the server answers, with a
+// few retries and exponential backoff in between. A client, which cannot
+// reach the server, is reported as storage.ErrUnavailable.
+func NewClient(config *config.Config) (*mongo.Client, error) {
+	var client *mongo.Client
+	err := retry(context.Background(), func() error {
+		var err error
+		client, err = connect(&config.Mongodb)
+		return err
+	})
+	if err != nil {
+		return nil, &storage.UnavailableError{Err: err}
+	}
+
+	return client, nil
+}
+
+// OpenClient creates a client, which connects in the background, without
+// waiting for the server. Operations fail with storage.ErrUnavailable while
+// the server cannot be reached, and the client keeps trying to reach it.
+func OpenClient(config *config.Config) (*mongo.Client, error) {
+	clientOptions, err := ClientOptions(&config.Mongodb)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := mongo.NewClient(clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.Connect(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// WaitReady pings the server of a client, which was opened by OpenClient,
+// like NewClient, until it answers or ctx ends.
+func WaitReady(ctx context.Context, client *mongo.Client, config *config.Config) error {
+	timeout := config.Mongodb.ConnectTimeout
+	if timeout == 0 {
+		timeout = defaultConnectTimeout
+	}
+
+	err := retry(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return client.Ping(ctx, readpref.Primary())
+	})
+	if err != nil {
+		return &storage.UnavailableError{Err: err}
+	}
+
+	return nil
+}
+
+// retry calls fn until it succeeds, at most connectAttempts times, with
+// exponential backoff in between. It returns the last error.
+func retry(ctx context.Context, fn func() error) error {
+	backoff := initialConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt == connectAttempts {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+
+		backoff *= 2
+	}
+}
+
+// NewDatabase opens MONGODB_DATABASE, even when the URI names another
+// database. The database of the URI is only its default authSource.
+func NewDatabase(client *mongo.Client, config *config.Config) *mongo.Database {
+	return client.Database(config.Mongodb.Database)
+}
+
+// ClientOptions builds the options from MONGODB_URI, or from the separate
+// host, port, user and password when no URI is given. Explicitly configured
+// options override the ones of the URI.
+func ClientOptions(config *config.Mongodb) (*options.ClientOptions, error) {
+	clientOptions := options.Client()
+	if len(config.Uri) != 0 {
+		clientOptions.ApplyURI(config.Uri)
+	} else {
+		clientOptions.ApplyURI("mongodb://" + config.Host + ":" + config.Port)
+		clientOptions.SetAuth(options.Credential{
+			AuthSource: config.AuthSource,
+			Username:   config.User,
+			Password:   config.Password,
+		})
+	}
+
+	if len(config.ReplicaSet) != 0 {
+		clientOptions.SetReplicaSet(config.ReplicaSet)
+	}
+
+	if config.MaxPoolSize != 0 {
+		clientOptions.SetMaxPoolSize(uint64(config.MaxPoolSize))
+	}
+
+	if config.MinPoolSize != 0 {
+		clientOptions.SetMinPoolSize(uint64(config.MinPoolSize))
+	}
+
+	if config.MaxConnIdleTime != 0 {
+		clientOptions.SetMaxConnIdleTime(config.MaxConnIdleTime)
+	}
+
+	if config.ConnectTimeout != 0 {
+		clientOptions.SetConnectTimeout(config.ConnectTimeout)
+	}
+
+	if config.ServerSelectionTimeout != 0 {
+		clientOptions.SetServerSelectionTimeout(config.ServerSelectionTimeout)
+	}
+
+	if len(config.TLSCAFile) != 0 || len(config.TLSCertificateKeyFile) != 0 {
+		tlsConfig, err := tlsConfig(config)
+		if err != nil {
+			return nil, err
+		}
+
+		clientOptions.SetTLSConfig(tlsConfig)
+	}
+
+	err := clientOptions.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return clientOptions, nil
+}
+
+// The options are built on every attempt, because a mongodb+srv:// URI is
+// resolved while they are built.
+func connect(config *config.Mongodb) (*mongo.Client, error) {
+	clientOptions, err := ClientOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := config.ConnectTimeout
+	if timeout == 0 {
+		timeout = defaultConnectTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.Ping(ctx, nil)
+	if err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func tlsConfig(config *config.Mongodb) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(config.TLSCAFile) != 0 {
+		pem, err := ioutil.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, ErrInvalidCAFile
+		}
+	}
+
+	// The certificate and the private key are expected in the same file,
+	// as with the tlsCertificateKeyFile option of the URI.
+	if len(config.TLSCertificateKeyFile) != 0 {
+		certificate, err := tls.LoadX509KeyPair(config.TLSCertificateKeyFile, config.TLSCertificateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,47 @@
+package mongodbtest
+
+import (
+	"context"
+	"os"
+	"survey-api/pkg/migration"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	uriEnv = "MONGODB_TEST_URI"
+)
+
+// Database returns a new database with every migration applied, which is
+// dropped after the test. The test is skipped unless MONGODB_TEST_URI
+// names a server to create it on.
+func Database(t *testing.T) *mongo.Database {
+	uri := os.Getenv(uriEnv)
+	if len(uri) == 0 {
+		t.Skip(uriEnv + " is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := client.Database("survey_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+
+	_, err = migration.NewMongodb(db).Up(ctx, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
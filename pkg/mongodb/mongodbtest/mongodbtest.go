@@ -0,0 +1,28 @@
+package mongodbtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Unreachable returns a database, whose operations never find a server, so
+// that they only return once their context is done.
+func Unreachable(t *testing.T) *mongo.Database {
+	clientOptions := options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(time.Hour)
+	client, err := mongo.Connect(context.Background(), clientOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		client.Disconnect(context.Background())
+	})
+
+	return client.Database("survey")
+}
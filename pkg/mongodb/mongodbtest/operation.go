@@ -0,0 +1,40 @@
+package mongodbtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Operation calls a single repository method with the given context.
+type Operation func(ctx context.Context) error
+
+// TestCancellation checks, that every operation stops as soon as its
+// context is cancelled, and that timeout bounds operations, whose context
+// has no deadline.
+func TestCancellation(t *testing.T, timeout time.Duration, operations map[string]Operation) {
+	for name, operation := range operations {
+		operation := operation
+		t.Run(name+"/cancelled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err := operation(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected %v, got %v", context.Canceled, err)
+			}
+		})
+
+		t.Run(name+"/timeout", func(t *testing.T) {
+			start := time.Now()
+			err := operation(context.Background())
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+			}
+
+			if elapsed := time.Since(start); elapsed > 10*timeout {
+				t.Fatalf("expected the operation to stop after %v, took %v", timeout, elapsed)
+			}
+		})
+	}
+}
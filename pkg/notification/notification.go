@@ -0,0 +1,144 @@
+package notification
+
+import (
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"strings"
+	"survey-api/pkg/config"
+	"survey-api/pkg/logger"
+	usermodel "survey-api/pkg/user/model"
+	"time"
+)
+
+const (
+	// Notifications are sent while the request waits, so a slow SMTP server
+	// must not hold it until the write timeout of the server.
+	sendTimeout = 5 * time.Second
+)
+
+type Service struct {
+	logger  *logger.Service
+	config  *config.Config
+	timeout time.Duration
+}
+
+func New(logger *logger.Service, config *config.Config) *Service {
+	return &Service{logger: logger, config: config, timeout: sendTimeout}
+}
+
+// Notifications are best effort, a failure to deliver one is logged but
+// never fails the operation that triggered it.
+func (s *Service) SendWelcome(user *usermodel.User) {
+	s.send(
+		user.Email,
+		"Welcome to Survey",
+		"Hi "+user.FirstName+",\r\n\r\n"+
+			"your account "+user.UserName+" was created successfully. You can now log in.",
+	)
+}
+
+// SendRegistrationAttempt tells the owner of an existing account that
+// somebody tried to register with their email. The caller responds the same
+// way as for a successful registration, so the email is never confirmed as
+// taken to anyone but its owner.
+func (s *Service) SendRegistrationAttempt(email string) {
+	s.send(
+		email,
+		"Registration attempt",
+		"Hi,\r\n\r\n"+
+			"somebody tried to register a new account with this email, which already has an account. "+
+			"If it was you, log in with your existing account instead. Otherwise, you can ignore this email.",
+	)
+}
+
+// When no SMTP server is configured, the notifications are only logged,
+// which is enough for local development.
+func (s *Service) send(to string, subject string, body string) {
+	smtpConfig := s.config.Smtp
+	if len(smtpConfig.Host) == 0 {
+		s.logger.Info("Notification not sent without an SMTP server", logger.Fields{"to": to, "subject": subject})
+		return
+	}
+
+	var auth smtp.Auth
+	if len(smtpConfig.User) != 0 {
+		auth = smtp.PlainAuth("", smtpConfig.User, smtpConfig.Password, smtpConfig.Host)
+	}
+
+	message := strings.Join([]string{
+		"From: " + smtpConfig.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"",
+		body,
+	}, "\r\n")
+	err := s.deliver(auth, to, []byte(message))
+	if err != nil {
+		s.logger.Error("Failed to send a notification", logger.Fields{"error": err, "subject": subject})
+	}
+}
+
+// deliver is smtp.SendMail with a deadline, which bounds the connection
+// and the whole conversation with the server.
+func (s *Service) deliver(auth smtp.Auth, to string, message []byte) error {
+	smtpConfig := s.config.Smtp
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(smtpConfig.Host, smtpConfig.Port), s.timeout)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+	err = conn.SetDeadline(time.Now().Add(s.timeout))
+	if err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, smtpConfig.Host)
+	if err != nil {
+		return err
+	}
+
+	defer client.Close()
+	ok, _ := client.Extension("STARTTLS")
+	if ok {
+		err = client.StartTLS(&tls.Config{ServerName: smtpConfig.Host})
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth != nil {
+		err = client.Auth(auth)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(smtpConfig.From)
+	if err != nil {
+		return err
+	}
+
+	err = client.Rcpt(to)
+	if err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(message)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,41 @@
+package notification
+
+import (
+	"net"
+	"survey-api/pkg/config"
+	"survey-api/pkg/logger"
+	"testing"
+	"time"
+)
+
+func TestDeliverTimesOut(t *testing.T) {
+	// The server accepts the connection, but never greets.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	conf := config.Default()
+	conf.Smtp.Host, conf.Smtp.Port, _ = net.SplitHostPort(listener.Addr().String())
+	s := New(&logger.Service{}, conf)
+	s.timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	err = s.deliver(nil, "alice@example.com", []byte("Subject: Test\r\n\r\nTest"))
+	if err == nil {
+		t.Fatal("expected the send to time out")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the send to be bounded by the timeout, took %v", elapsed)
+	}
+}
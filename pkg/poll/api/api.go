@@ -1,102 +1,71 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
+	"survey-api/pkg/apperror"
 	authhandler "survey-api/pkg/auth/handler"
 	"survey-api/pkg/di"
-	"survey-api/pkg/logger"
+	"survey-api/pkg/endpoint"
 	pollhandler "survey-api/pkg/poll/handler"
 	"survey-api/pkg/poll/model"
+	"survey-api/pkg/router"
 )
 
 const (
-	queryId = "id"
+	paramId = "id"
 )
 
-type dependencies struct {
-	logger      *logger.Service
-	authHandler *authhandler.Service
-	pollHandler *pollhandler.Service
+var handler = endpoint.Serverless(Routes)
+
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler(w, r)
 }
 
-var handler func(http.ResponseWriter, *http.Request)
+func Routes(rt *router.Router, container *di.Dependencies) {
+	Init(rt, container.AuthHandler, container.PollHandler)
+}
 
-func Handler() func(http.ResponseWriter, *http.Request) {
-	return handler
+func Init(rt *router.Router, authHandler *authhandler.Service, pollHandler *pollhandler.Service) {
+	rt.Handle(http.MethodPost, "/poll", handlePost(pollHandler), authHandler.Authenticate)
+	rt.Handle(http.MethodDelete, "/poll/{"+paramId+"}", handleDelete(pollHandler), authHandler.Authenticate)
+	// Deprecated, the id used to be sent as a query parameter.
+	rt.Handle(http.MethodDelete, "/poll", handleDelete(pollHandler), authHandler.Authenticate)
 }
 
-func Init(
-	deps *dependencies,
-) func(http.ResponseWriter, *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userId, err := deps.authHandler.AuthToken(r)
+func handlePost(pollHandler *pollhandler.Service) router.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		createPoll := &model.CreatePoll{}
+		err := router.DecodeJSON(r, createPoll)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			return
+			return err
 		}
 
-		switch r.Method {
-		case http.MethodPost:
-			handlePost(w, r, userId, deps)
-		case http.MethodDelete:
-			handleDelete(w, r, userId, deps)
-		default:
-			w.WriteHeader(http.StatusNotFound)
+		poll, err := pollHandler.CreatePoll(r.Context(), authhandler.UserId(r), createPoll)
+		if err != nil {
+			return err
 		}
-	}
-}
-
-func handlePost(w http.ResponseWriter, r *http.Request, userId string, deps *dependencies) {
-	var createPoll *model.CreatePoll
-	err := json.NewDecoder(r.Body).Decode(&createPoll)
-	if err != nil {
-		deps.logger.LogErr(err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	poll, err := deps.pollHandler.CreatePoll(userId, createPoll)
-	if err != nil {
-		deps.logger.LogErr(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
 
-	result, err := json.Marshal(poll.ToPollClient())
-	if err != nil {
-		deps.logger.LogErr(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return router.WriteJSON(w, http.StatusOK, poll.ToPollClient())
 	}
-
-	w.WriteHeader(http.StatusOK)
-	w.Write(result)
 }
 
-func handleDelete(w http.ResponseWriter, r *http.Request, userId string, deps *dependencies) {
-	pollId := r.URL.Query().Get(queryId)
-	if len(pollId) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
+func handleDelete(pollHandler *pollhandler.Service) router.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		pollId := router.Param(r, paramId)
+		if len(pollId) == 0 {
+			pollId = r.URL.Query().Get(paramId)
+		}
 
-	err := deps.pollHandler.DeletePoll(userId, pollId)
-	if err != nil {
-		deps.logger.LogErr(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
+		if len(pollId) == 0 {
+			return apperror.InvalidField(paramId, "cannot be blank")
+		}
 
-	w.WriteHeader(http.StatusOK)
-}
+		err := pollHandler.DeletePoll(r.Context(), authhandler.UserId(r), pollId)
+		if err != nil {
+			return err
+		}
 
-func init() {
-	handler = Init(
-		&dependencies{
-			logger:      di.Container().Logger,
-			authHandler: di.Container().AuthHandler,
-			pollHandler: di.Container().PollHandler,
-		},
-	)
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
 }
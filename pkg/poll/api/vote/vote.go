@@ -1,75 +1,38 @@
 package vote
 
 import (
-	"encoding/json"
 	"net/http"
 	authhandler "survey-api/pkg/auth/handler"
 	"survey-api/pkg/di"
-	"survey-api/pkg/logger"
+	"survey-api/pkg/endpoint"
 	pollhandler "survey-api/pkg/poll/handler"
 	"survey-api/pkg/poll/model"
+	"survey-api/pkg/router"
 )
 
-type dependencies struct {
-	logger      *logger.Service
-	authHandler *authhandler.Service
-	pollHandler *pollhandler.Service
-}
-
-var handler func(http.ResponseWriter, *http.Request)
+var handler = endpoint.Serverless(Routes)
 
-func Handler() func(http.ResponseWriter, *http.Request) {
-	return handler
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler(w, r)
 }
 
-func Init(
-	deps *dependencies,
-) func(http.ResponseWriter, *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userId, err := deps.authHandler.AuthToken(r)
-		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			return
-		}
-
-		if r.Method != http.MethodPut {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-
-		var pollVote *model.PollVote
-		err = json.NewDecoder(r.Body).Decode(&pollVote)
-		if err != nil {
-			deps.logger.LogErr(err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
+func Routes(rt *router.Router, container *di.Dependencies) {
+	Init(rt, container.AuthHandler, container.PollHandler)
+}
 
-		poll, err := deps.pollHandler.AddPollVote(userId, pollVote)
+func Init(rt *router.Router, authHandler *authhandler.Service, pollHandler *pollhandler.Service) {
+	rt.Handle(http.MethodPut, "/poll/vote", func(w http.ResponseWriter, r *http.Request) error {
+		pollVote := &model.PollVote{}
+		err := router.DecodeJSON(r, pollVote)
 		if err != nil {
-			deps.logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return err
 		}
 
-		result, err := json.Marshal(poll.ToPollClient())
+		poll, err := pollHandler.AddPollVote(r.Context(), authhandler.UserId(r), pollVote)
 		if err != nil {
-			deps.logger.LogErr(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return err
 		}
 
-		w.WriteHeader(http.StatusOK)
-		w.Write(result)
-	}
-}
-
-func init() {
-	handler = Init(
-		&dependencies{
-			logger:      di.Container().Logger,
-			authHandler: di.Container().AuthHandler,
-			pollHandler: di.Container().PollHandler,
-		},
-	)
+		return router.WriteJSON(w, http.StatusOK, poll.ToPollClient())
+	}, authHandler.Authenticate)
 }
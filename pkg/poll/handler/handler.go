@@ -1,26 +1,52 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"strconv"
+	"survey-api/pkg/apperror"
+	audithandler "survey-api/pkg/audit/handler"
+	auditmodel "survey-api/pkg/audit/model"
+	"survey-api/pkg/metrics"
 	"survey-api/pkg/poll/model"
 	"survey-api/pkg/poll/repo"
+	"survey-api/pkg/storage"
+	"survey-api/pkg/tracing"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	ErrPollNotFound   = apperror.NotFound("Poll not found")
+	ErrVoteNotAllowed = apperror.Forbidden("Cannot vote for this poll")
+	ErrAlreadyVoted   = apperror.Conflict("User already voted for this poll")
+	ErrNotPollOwner   = apperror.Forbidden("User cannot delete this poll")
+	ErrInvalidIndex   = apperror.InvalidField("index", "is out of range")
+)
+
 type Service struct {
-	pollRepo *repo.Service
+	pollRepo     repo.Repository
+	metrics      *metrics.Service
+	tracing      *tracing.Service
+	auditHandler *audithandler.Service
 }
 
-func New(pollRepo *repo.Service) *Service {
-	return &Service{pollRepo: pollRepo}
+func New(
+	pollRepo repo.Repository,
+	metrics *metrics.Service,
+	tracing *tracing.Service,
+	auditHandler *audithandler.Service,
+) *Service {
+	return &Service{pollRepo: pollRepo, metrics: metrics, tracing: tracing, auditHandler: auditHandler}
 }
 
-func (s *Service) CreatePoll(userId string, createPoll *model.CreatePoll) (*model.Poll, error) {
+func (s *Service) CreatePoll(ctx context.Context, userId string, createPoll *model.CreatePoll) (*model.Poll, error) {
+	ctx, span := s.tracing.Start(ctx, "pollhandler.CreatePoll")
+	defer span.End()
+
 	err := createPoll.Validate()
 	if err != nil {
-		return nil, err
+		return nil, apperror.Validation(err)
 	}
 
 	poll, err := createPoll.ToPoll(userId)
@@ -28,36 +54,36 @@ func (s *Service) CreatePoll(userId string, createPoll *model.CreatePoll) (*mode
 		return nil, err
 	}
 
-	poll, err = s.pollRepo.InsertOne(poll)
+	poll, err = s.pollRepo.InsertOne(ctx, poll)
 	if err != nil {
 		return nil, err
 	}
 
+	s.metrics.PollCreated()
 	return poll, err
 }
 
-func (s *Service) AddPollVote(userIdString string, pollVote *model.PollVote) (*model.Poll, error) {
+func (s *Service) AddPollVote(ctx context.Context, userIdString string, pollVote *model.PollVote) (*model.Poll, error) {
+	ctx, span := s.tracing.Start(ctx, "pollhandler.AddPollVote")
+	defer span.End()
+
 	err := pollVote.Validate()
 	if err != nil {
-		return nil, err
+		return nil, apperror.Validation(err)
 	}
 
-	poll, err := s.pollRepo.FindById(pollVote.PollId)
+	poll, err := s.findPoll(ctx, pollVote.PollId)
 	if err != nil {
 		return nil, err
 	}
 
 	if poll.Visibility != model.Public {
-		return nil, errors.New("Cannot vote for this poll")
+		return nil, ErrVoteNotAllowed
 	}
 
 	index, err := strconv.Atoi(pollVote.Index)
-	if err != nil {
-		return nil, err
-	}
-
-	if index < 0 || index >= len(poll.Options) {
-		return nil, errors.New("Index is out of range")
+	if err != nil || index < 0 || index >= len(poll.Options) {
+		return nil, ErrInvalidIndex
 	}
 
 	userId, err := primitive.ObjectIDFromHex(userIdString)
@@ -67,34 +93,76 @@ func (s *Service) AddPollVote(userIdString string, pollVote *model.PollVote) (*m
 
 	for i := range poll.VoterIds {
 		if poll.VoterIds[i] == userId {
-			return nil, errors.New("User already voted for this poll")
+			return nil, ErrAlreadyVoted
 		}
 	}
 
-	poll.VoterIds = append(poll.VoterIds, userId)
-	poll.Options[index].Count++
-	poll, err = s.pollRepo.UpdateOne(poll)
+	// The vote is added atomically, so that concurrent votes are not lost,
+	// and a concurrent vote of the same user is still rejected.
+	poll, err = s.pollRepo.AddVote(ctx, poll.Id, userId, index)
+	if errors.Is(err, storage.ErrDuplicate) {
+		return nil, ErrAlreadyVoted
+	}
+
+	if err == storage.ErrNotFound {
+		return nil, ErrPollNotFound
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
+	s.metrics.VoteCast()
 	return poll, nil
 }
 
-func (s *Service) DeletePoll(userId string, pollId string) error {
-	poll, err := s.pollRepo.FindById(pollId)
+func (s *Service) DeletePoll(ctx context.Context, userId string, pollId string) error {
+	ctx, span := s.tracing.Start(ctx, "pollhandler.DeletePoll")
+	defer span.End()
+
+	poll, err := s.findPoll(ctx, pollId)
+	if err == ErrPollNotFound {
+		s.recordDeletion(ctx, userId, pollId, auditmodel.OutcomeFailure, "Poll not found")
+	}
+
 	if err != nil {
 		return err
 	}
 
 	if poll.OwnerId.Hex() != userId {
-		return errors.New("User cannot delete this poll")
+		s.recordDeletion(ctx, userId, pollId, auditmodel.OutcomeFailure, "Not the owner of the poll")
+		return ErrNotPollOwner
 	}
 
-	err = s.pollRepo.DeleteOne(poll)
+	err = s.pollRepo.DeleteOne(ctx, poll)
 	if err != nil {
 		return err
 	}
 
+	s.recordDeletion(ctx, userId, pollId, auditmodel.OutcomeSuccess, "")
 	return nil
 }
+
+func (s *Service) recordDeletion(ctx context.Context, userId string, pollId string, outcome string, reason string) {
+	actorId, _ := primitive.ObjectIDFromHex(userId)
+	s.auditHandler.Record(ctx, &auditmodel.Event{
+		ActorId: actorId,
+		Action:  auditmodel.ActionDeletePoll,
+		Target:  pollId,
+		Outcome: outcome,
+		Reason:  reason,
+	})
+}
+
+func (s *Service) findPoll(ctx context.Context, pollId string) (*model.Poll, error) {
+	poll, err := s.pollRepo.FindById(ctx, pollId)
+	if err == storage.ErrNotFound {
+		return nil, ErrPollNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return poll, nil
+}
@@ -0,0 +1,30 @@
+package repo_test
+
+import (
+	"survey-api/pkg/config"
+	"survey-api/pkg/mongodb/mongodbtest"
+	"survey-api/pkg/poll/repo"
+	"survey-api/pkg/poll/repo/repotest"
+	"survey-api/pkg/postgres/postgrestest"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repotest.Storage {
+		return &repotest.Storage{Repository: repo.NewMemory(), NewUserId: repotest.AnyUserId}
+	})
+}
+
+func TestMongodb(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repotest.Storage {
+		db := mongodbtest.Database(t)
+		return &repotest.Storage{Repository: repo.New(db, config.Default()), NewUserId: repotest.AnyUserId}
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repotest.Storage {
+		db := postgrestest.DB(t)
+		return &repotest.Storage{Repository: repo.NewPostgres(db, config.Default()), NewUserId: postgrestest.UserIds(db)}
+	})
+}
@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"survey-api/pkg/metrics"
+	"survey-api/pkg/poll/model"
+	"survey-api/pkg/tracing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+// Instrumented measures the duration of every operation of a Repository
+// and traces it.
+type Instrumented struct {
+	next    Repository
+	observe func(method string, start time.Time, err error)
+	tracing *tracing.Service
+	storage string
+}
+
+func NewInstrumented(next Repository, metrics *metrics.Service, tracingService *tracing.Service, storage string) *Instrumented {
+	return &Instrumented{
+		next:    next,
+		observe: metrics.StorageObserver(storage, "poll"),
+		tracing: tracingService,
+		storage: storage,
+	}
+}
+
+// The returned function ends the operation.
+func (i *Instrumented) begin(ctx context.Context, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := i.tracing.Start(ctx, "poll."+method,
+		semconv.DBSystemKey.String(i.storage),
+		semconv.DBOperationKey.String(method),
+	)
+	return ctx, func(err error) {
+		tracing.End(span, err)
+		i.observe(method, start, err)
+	}
+}
+
+func (i *Instrumented) InsertOne(ctx context.Context, poll *model.Poll) (*model.Poll, error) {
+	ctx, end := i.begin(ctx, "InsertOne")
+	poll, err := i.next.InsertOne(ctx, poll)
+	end(err)
+	return poll, err
+}
+
+func (i *Instrumented) FindById(ctx context.Context, pollId string) (*model.Poll, error) {
+	ctx, end := i.begin(ctx, "FindById")
+	poll, err := i.next.FindById(ctx, pollId)
+	end(err)
+	return poll, err
+}
+
+func (i *Instrumented) FindOne(ctx context.Context, pollFilter *model.Poll) (*model.Poll, error) {
+	ctx, end := i.begin(ctx, "FindOne")
+	poll, err := i.next.FindOne(ctx, pollFilter)
+	end(err)
+	return poll, err
+}
+
+func (i *Instrumented) UpdateOne(ctx context.Context, poll *model.Poll) (*model.Poll, error) {
+	ctx, end := i.begin(ctx, "UpdateOne")
+	poll, err := i.next.UpdateOne(ctx, poll)
+	end(err)
+	return poll, err
+}
+
+func (i *Instrumented) AddVote(ctx context.Context, pollId primitive.ObjectID, voterId primitive.ObjectID, position int) (*model.Poll, error) {
+	ctx, end := i.begin(ctx, "AddVote")
+	poll, err := i.next.AddVote(ctx, pollId, voterId, position)
+	end(err)
+	return poll, err
+}
+
+func (i *Instrumented) DeleteOne(ctx context.Context, poll *model.Poll) error {
+	ctx, end := i.begin(ctx, "DeleteOne")
+	err := i.next.DeleteOne(ctx, poll)
+	end(err)
+	return err
+}
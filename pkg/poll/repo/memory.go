@@ -0,0 +1,194 @@
+package repo
+
+import (
+	"context"
+	"reflect"
+	"survey-api/pkg/poll/model"
+	"survey-api/pkg/storage"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Memory is an in-memory implementation of Repository for tests and local
+// demos. It is safe for concurrent use.
+type Memory struct {
+	mutex sync.RWMutex
+	polls map[primitive.ObjectID]*model.Poll
+}
+
+func NewMemory() *Memory {
+	return &Memory{polls: make(map[primitive.ObjectID]*model.Poll)}
+}
+
+func (m *Memory) InsertOne(ctx context.Context, p *model.Poll) (*model.Poll, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	p.LastModified = primitive.NewDateTimeFromTime(time.Now().UTC())
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if p.Id.IsZero() {
+		p.Id = primitive.NewObjectID()
+	}
+
+	_, ok := m.polls[p.Id]
+	if ok {
+		return nil, &storage.DuplicateError{}
+	}
+
+	m.polls[p.Id] = clonePoll(p)
+	return p, nil
+}
+
+func (m *Memory) FindById(ctx context.Context, pollIdString string) (*model.Poll, error) {
+	pollId, err := primitive.ObjectIDFromHex(pollIdString)
+	if err != nil || pollId.IsZero() {
+		return nil, storage.ErrNotFound
+	}
+
+	return m.FindOne(ctx, &model.Poll{Id: pollId})
+}
+
+func (m *Memory) FindOne(ctx context.Context, pollFilter *model.Poll) (*model.Poll, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for _, poll := range m.polls {
+		if matchesPoll(pollFilter, poll) {
+			return clonePoll(poll), nil
+		}
+	}
+
+	return nil, storage.ErrNotFound
+}
+
+func (m *Memory) UpdateOne(ctx context.Context, poll *model.Poll) (*model.Poll, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	poll.LastModified = primitive.NewDateTimeFromTime(time.Now())
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	stored, ok := m.polls[poll.Id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	updated := clonePoll(stored)
+	set := clonePoll(poll)
+	if !set.OwnerId.IsZero() {
+		updated.OwnerId = set.OwnerId
+	}
+
+	if len(set.Content) != 0 {
+		updated.Content = set.Content
+	}
+
+	if len(set.Options) != 0 {
+		updated.Options = set.Options
+	}
+
+	if len(set.Visibility) != 0 {
+		updated.Visibility = set.Visibility
+	}
+
+	if len(set.VoterIds) != 0 {
+		updated.VoterIds = set.VoterIds
+	}
+
+	if set.Created != 0 {
+		updated.Created = set.Created
+	}
+
+	if set.Closed != 0 {
+		updated.Closed = set.Closed
+	}
+
+	updated.LastModified = set.LastModified
+	m.polls[poll.Id] = updated
+	return poll, nil
+}
+
+func (m *Memory) AddVote(ctx context.Context, pollId primitive.ObjectID, voterId primitive.ObjectID, position int) (*model.Poll, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	stored, ok := m.polls[pollId]
+	if !ok || position < 0 || position >= len(stored.Options) {
+		return nil, storage.ErrNotFound
+	}
+
+	for _, id := range stored.VoterIds {
+		if id == voterId {
+			return nil, &storage.DuplicateError{Field: "voter_id"}
+		}
+	}
+
+	updated := clonePoll(stored)
+	updated.VoterIds = append(updated.VoterIds, voterId)
+	updated.Options[position].Count++
+	updated.LastModified = primitive.NewDateTimeFromTime(time.Now())
+	m.polls[pollId] = updated
+	return clonePoll(updated), nil
+}
+
+func (m *Memory) DeleteOne(ctx context.Context, poll *model.Poll) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for id, other := range m.polls {
+		if matchesPoll(poll, other) {
+			delete(m.polls, id)
+			return nil
+		}
+	}
+
+	return storage.ErrNotFound
+}
+
+func matchesPoll(filter *model.Poll, poll *model.Poll) bool {
+	return (filter.Id.IsZero() || filter.Id == poll.Id) &&
+		(filter.OwnerId.IsZero() || filter.OwnerId == poll.OwnerId) &&
+		(len(filter.Content) == 0 || filter.Content == poll.Content) &&
+		(len(filter.Options) == 0 || reflect.DeepEqual(filter.Options, poll.Options)) &&
+		(len(filter.Visibility) == 0 || filter.Visibility == poll.Visibility) &&
+		(len(filter.VoterIds) == 0 || reflect.DeepEqual(filter.VoterIds, poll.VoterIds)) &&
+		(filter.Created == 0 || filter.Created == poll.Created) &&
+		(filter.Closed == 0 || filter.Closed == poll.Closed) &&
+		(filter.LastModified == 0 || filter.LastModified == poll.LastModified)
+}
+
+// Polls are copied in and out, so that callers never share the stored
+// options and voters.
+func clonePoll(poll *model.Poll) *model.Poll {
+	clone := *poll
+	if poll.Options != nil {
+		clone.Options = append([]model.PollOption(nil), poll.Options...)
+	}
+
+	if poll.VoterIds != nil {
+		clone.VoterIds = append([]primitive.ObjectID(nil), poll.VoterIds...)
+	}
+
+	return &clone
+}
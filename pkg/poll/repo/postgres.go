@@ -0,0 +1,398 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"survey-api/pkg/config"
+	"survey-api/pkg/poll/model"
+	"survey-api/pkg/postgres"
+	"survey-api/pkg/storage"
+	"time"
+
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	pollColumns = "id, owner_id, content, visibility, created, closed, last_modified"
+)
+
+var (
+	// A voter, who is added twice by concurrent votes, violates the unique
+	// key of the votes.
+	constraintFields = map[string]string{
+		"votes_poll_id_voter_id_key": "voter_id",
+	}
+)
+
+// Postgres is the PostgreSQL implementation of Repository. The options and
+// the voters of a poll are kept in tables of their own, which are always
+// written together with the poll. Filters only match the columns of the
+// polls, not the options and voters.
+type Postgres struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewPostgres(db *sql.DB, config *config.Config) *Postgres {
+	return &Postgres{db: db, timeout: config.Postgres.OperationTimeout}
+}
+
+func (p *Postgres) InsertOne(ctx context.Context, poll *model.Poll) (*model.Poll, error) {
+	if poll.Id.IsZero() {
+		poll.Id = primitive.NewObjectID()
+	}
+
+	poll.LastModified = primitive.NewDateTimeFromTime(time.Now().UTC())
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	err := p.inTx(ctx, nil, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO polls ("+pollColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7)",
+			poll.Id.Hex(), poll.OwnerId.Hex(), poll.Content, string(poll.Visibility),
+			postgres.NullTime(poll.Created), postgres.NullTime(poll.Closed), poll.LastModified.Time().UTC())
+		if err != nil {
+			return err
+		}
+
+		err = insertOptions(ctx, tx, poll)
+		if err != nil {
+			return err
+		}
+
+		return insertVoters(ctx, tx, poll)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return poll, nil
+}
+
+func (p *Postgres) FindById(ctx context.Context, pollIdString string) (*model.Poll, error) {
+	pollId, err := primitive.ObjectIDFromHex(pollIdString)
+	if err != nil || pollId.IsZero() {
+		return nil, storage.ErrNotFound
+	}
+
+	return p.FindOne(ctx, &model.Poll{Id: pollId})
+}
+
+func (p *Postgres) FindOne(ctx context.Context, pollFilter *model.Poll) (*model.Poll, error) {
+	var params postgres.Params
+	conditions := pollConditions(&params, pollFilter)
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	var poll *model.Poll
+	// The poll, its options and its voters are read from one snapshot.
+	readOnly := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	err := p.inTx(ctx, readOnly, func(tx *sql.Tx) error {
+		var err error
+		poll, err = findPoll(ctx, tx, conditions, params.Args())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return poll, nil
+}
+
+func (p *Postgres) UpdateOne(ctx context.Context, poll *model.Poll) (*model.Poll, error) {
+	poll.LastModified = primitive.NewDateTimeFromTime(time.Now().UTC())
+
+	var params postgres.Params
+	sets := []string{"last_modified = " + params.Add(poll.LastModified.Time().UTC())}
+	if !poll.OwnerId.IsZero() {
+		sets = append(sets, "owner_id = "+params.Add(poll.OwnerId.Hex()))
+	}
+
+	if len(poll.Content) != 0 {
+		sets = append(sets, "content = "+params.Add(poll.Content))
+	}
+
+	if len(poll.Visibility) != 0 {
+		sets = append(sets, "visibility = "+params.Add(string(poll.Visibility)))
+	}
+
+	if poll.Created != 0 {
+		sets = append(sets, "created = "+params.Add(poll.Created.Time().UTC()))
+	}
+
+	if poll.Closed != 0 {
+		sets = append(sets, "closed = "+params.Add(poll.Closed.Time().UTC()))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	err := p.inTx(ctx, nil, func(tx *sql.Tx) error {
+		statement := "UPDATE polls SET " + strings.Join(sets, ", ") + " WHERE id = " + params.Add(poll.Id.Hex())
+		result, err := tx.ExecContext(ctx, statement, params.Args()...)
+		if err != nil {
+			return err
+		}
+
+		err = postgres.ExpectRows(result)
+		if err != nil {
+			return err
+		}
+
+		if len(poll.Options) != 0 {
+			_, err = tx.ExecContext(ctx, "DELETE FROM poll_options WHERE poll_id = $1", poll.Id.Hex())
+			if err != nil {
+				return err
+			}
+
+			err = insertOptions(ctx, tx, poll)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Voters are only added, so that the votes of others, which were
+		// added concurrently, are kept.
+		return insertVoters(ctx, tx, poll)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return poll, nil
+}
+
+// The vote row and the count are written by single statements instead of
+// the whole poll, so that concurrent votes of other voters are all
+// counted. A second vote of the voter violates the unique key of the votes.
+func (p *Postgres) AddVote(ctx context.Context, pollId primitive.ObjectID, voterId primitive.ObjectID, position int) (*model.Poll, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	var poll *model.Poll
+	err := p.inTx(ctx, nil, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "UPDATE poll_options SET count = count + 1 WHERE poll_id = $1 AND position = $2",
+			pollId.Hex(), position)
+		if err != nil {
+			return err
+		}
+
+		err = postgres.ExpectRows(result)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO votes (poll_id, voter_id) VALUES ($1, $2)", pollId.Hex(), voterId.Hex())
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE polls SET last_modified = $1 WHERE id = $2", time.Now().UTC(), pollId.Hex())
+		if err != nil {
+			return err
+		}
+
+		poll, err = findPoll(ctx, tx, []string{"id = $1"}, []interface{}{pollId.Hex()})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return poll, nil
+}
+
+func (p *Postgres) DeleteOne(ctx context.Context, poll *model.Poll) error {
+	var params postgres.Params
+	conditions := pollConditions(&params, poll)
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	// The options and the voters are deleted by the cascading foreign keys.
+	statement := "DELETE FROM polls WHERE id = (SELECT id FROM polls" + postgres.Where(conditions) + " LIMIT 1)"
+	result, err := p.db.ExecContext(ctx, statement, params.Args()...)
+	if err != nil {
+		return storage.FromPostgres(err, constraintFields)
+	}
+
+	return postgres.ExpectRows(result)
+}
+
+// Runs fn in a transaction, which is rolled back when fn fails.
+func (p *Postgres) inTx(ctx context.Context, options *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	tx, err := p.db.BeginTx(ctx, options)
+	if err != nil {
+		return storage.FromPostgres(err, constraintFields)
+	}
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return storage.FromPostgres(err, constraintFields)
+	}
+
+	return storage.FromPostgres(tx.Commit(), constraintFields)
+}
+
+func pollConditions(params *postgres.Params, filter *model.Poll) []string {
+	var conditions []string
+	if !filter.Id.IsZero() {
+		conditions = append(conditions, "id = "+params.Add(filter.Id.Hex()))
+	}
+
+	if !filter.OwnerId.IsZero() {
+		conditions = append(conditions, "owner_id = "+params.Add(filter.OwnerId.Hex()))
+	}
+
+	if len(filter.Content) != 0 {
+		conditions = append(conditions, "content = "+params.Add(filter.Content))
+	}
+
+	if len(filter.Visibility) != 0 {
+		conditions = append(conditions, "visibility = "+params.Add(string(filter.Visibility)))
+	}
+
+	if filter.Created != 0 {
+		conditions = append(conditions, "created = "+params.Add(filter.Created.Time().UTC()))
+	}
+
+	if filter.Closed != 0 {
+		conditions = append(conditions, "closed = "+params.Add(filter.Closed.Time().UTC()))
+	}
+
+	if filter.LastModified != 0 {
+		conditions = append(conditions, "last_modified = "+params.Add(filter.LastModified.Time().UTC()))
+	}
+
+	return conditions
+}
+
+func findPoll(ctx context.Context, tx *sql.Tx, conditions []string, args []interface{}) (*model.Poll, error) {
+	row := tx.QueryRowContext(ctx, "SELECT "+pollColumns+" FROM polls"+postgres.Where(conditions)+" LIMIT 1", args...)
+	poll, err := scanPoll(row)
+	if err != nil {
+		return nil, err
+	}
+
+	poll.Options, err = findOptions(ctx, tx, poll.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	poll.VoterIds, err = findVoters(ctx, tx, poll.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return poll, nil
+}
+
+func scanPoll(row *sql.Row) (*model.Poll, error) {
+	var poll model.Poll
+	var id, ownerId, visibility string
+	var created, closed *time.Time
+	var lastModified time.Time
+	err := row.Scan(&id, &ownerId, &poll.Content, &visibility, &created, &closed, &lastModified)
+	if err != nil {
+		return nil, err
+	}
+
+	poll.Visibility = model.PollVisibility(visibility)
+	poll.Created = postgres.DateTime(created)
+	poll.Closed = postgres.DateTime(closed)
+	poll.LastModified = primitive.NewDateTimeFromTime(lastModified)
+	poll.Id, err = postgres.ObjectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	poll.OwnerId, err = postgres.ObjectID(ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &poll, nil
+}
+
+func insertOptions(ctx context.Context, tx *sql.Tx, poll *model.Poll) error {
+	for position, option := range poll.Options {
+		_, err := tx.ExecContext(ctx, "INSERT INTO poll_options (poll_id, position, option_index, content, count) VALUES ($1, $2, $3, $4, $5)",
+			poll.Id.Hex(), position, option.Index, option.Content, option.Count)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findOptions(ctx context.Context, tx *sql.Tx, pollId primitive.ObjectID) ([]model.PollOption, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT option_index, content, count FROM poll_options WHERE poll_id = $1 ORDER BY position", pollId.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var options []model.PollOption
+	for rows.Next() {
+		var option model.PollOption
+		err = rows.Scan(&option.Index, &option.Content, &option.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, option)
+	}
+
+	return options, rows.Err()
+}
+
+// Only the voters, who are not stored yet, are inserted, in their order.
+// Two concurrent votes of the same voter both miss the other one, so the
+// later one violates the unique key and is rolled back with its count.
+func insertVoters(ctx context.Context, tx *sql.Tx, poll *model.Poll) error {
+	if len(poll.VoterIds) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO votes (poll_id, voter_id)
+		SELECT $1::CHAR(24), voter.id FROM unnest($2::TEXT[]) WITH ORDINALITY AS voter (id, position)
+		WHERE NOT EXISTS (SELECT 1 FROM votes WHERE poll_id = $1::CHAR(24) AND voter_id = voter.id)
+		ORDER BY voter.position`,
+		poll.Id.Hex(), pq.Array(hexIds(poll.VoterIds)))
+	return err
+}
+
+func findVoters(ctx context.Context, tx *sql.Tx, pollId primitive.ObjectID) ([]primitive.ObjectID, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT voter_id FROM votes WHERE poll_id = $1 ORDER BY seq", pollId.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var voterIds []primitive.ObjectID
+	for rows.Next() {
+		var hex string
+		err = rows.Scan(&hex)
+		if err != nil {
+			return nil, err
+		}
+
+		voterId, err := postgres.ObjectID(hex)
+		if err != nil {
+			return nil, err
+		}
+
+		voterIds = append(voterIds, voterId)
+	}
+
+	return voterIds, rows.Err()
+}
+
+func hexIds(ids []primitive.ObjectID) []string {
+	hexes := make([]string, len(ids))
+	for i, id := range ids {
+		hexes[i] = id.Hex()
+	}
+
+	return hexes
+}
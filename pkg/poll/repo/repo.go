@@ -2,57 +2,78 @@ package repo
 
 import (
 	"context"
+	"strconv"
+	"survey-api/pkg/config"
 	"survey-api/pkg/poll/model"
+	"survey-api/pkg/storage"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type Service struct {
-	client *mongo.Client
+// Repository stores the polls. Missing polls are reported with
+// storage.ErrNotFound.
+type Repository interface {
+	InsertOne(ctx context.Context, poll *model.Poll) (*model.Poll, error)
+	FindById(ctx context.Context, pollId string) (*model.Poll, error)
+	// FindOne matches the non-zero fields of the filter.
+	FindOne(ctx context.Context, pollFilter *model.Poll) (*model.Poll, error)
+	// UpdateOne sets the non-zero fields of the poll.
+	UpdateOne(ctx context.Context, poll *model.Poll) (*model.Poll, error)
+	// AddVote adds the voter and counts the vote for the option at the
+	// position in one atomic update, so that concurrent votes are all
+	// counted. A voter, who already voted, is reported with
+	// storage.ErrDuplicate.
+	AddVote(ctx context.Context, pollId primitive.ObjectID, voterId primitive.ObjectID, position int) (*model.Poll, error)
+	// DeleteOne deletes a poll, which matches the non-zero fields of the
+	// given one.
+	DeleteOne(ctx context.Context, poll *model.Poll) error
 }
 
-func New(client *mongo.Client) (*Service, error) {
-	repo := &Service{client: client}
-	err := repo.createPollIndexes()
-	if err != nil {
-		return nil, err
-	}
+// Service is the MongoDB implementation of Repository.
+type Service struct {
+	db      *mongo.Database
+	timeout time.Duration
+}
 
-	return repo, nil
+func New(db *mongo.Database, config *config.Config) *Service {
+	return &Service{db: db, timeout: config.Mongodb.OperationTimeout}
 }
 
-func (s *Service) InsertOne(p *model.Poll) (*model.Poll, error) {
+func (s *Service) InsertOne(ctx context.Context, p *model.Poll) (*model.Poll, error) {
 	p.LastModified = primitive.NewDateTimeFromTime(time.Now().UTC())
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 	_, err := s.pollCollection().InsertOne(ctx, p)
 	if err != nil {
-		return nil, err
+		return nil, storage.FromMongo(err, nil)
 	}
 
 	return p, nil
 }
 
-func (s *Service) FindById(pollIdString string) (*model.Poll, error) {
+func (s *Service) FindById(ctx context.Context, pollIdString string) (*model.Poll, error) {
 	pollId, err := primitive.ObjectIDFromHex(pollIdString)
-	if err != nil {
-		return nil, err
+	// A zero id is left out of the filter, which would then match any
+	// document.
+	if err != nil || pollId.IsZero() {
+		return nil, storage.ErrNotFound
 	}
 
-	return s.FindOne(&model.Poll{Id: pollId})
+	return s.FindOne(ctx, &model.Poll{Id: pollId})
 }
 
-func (s *Service) FindOne(pollFilter *model.Poll) (*model.Poll, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (s *Service) FindOne(ctx context.Context, pollFilter *model.Poll) (*model.Poll, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 	result := s.pollCollection().FindOne(ctx, pollFilter)
 	err := result.Err()
 	if err != nil {
-		return nil, err
+		return nil, storage.FromMongo(err, nil)
 	}
 
 	var poll *model.Poll
@@ -64,51 +85,79 @@ func (s *Service) FindOne(pollFilter *model.Poll) (*model.Poll, error) {
 	return poll, nil
 }
 
-func (s *Service) UpdateOne(poll *model.Poll) (*model.Poll, error) {
+func (s *Service) UpdateOne(ctx context.Context, poll *model.Poll) (*model.Poll, error) {
 	poll.LastModified = primitive.NewDateTimeFromTime(time.Now())
 	pollFilter := &model.Poll{Id: poll.Id}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 	result := s.pollCollection().FindOneAndUpdate(ctx, pollFilter, bson.M{"$set": poll})
 	err := result.Err()
 	if err != nil {
-		return nil, err
+		return nil, storage.FromMongo(err, nil)
 	}
 
 	return poll, nil
 }
 
-func (s *Service) DeleteOne(poll *model.Poll) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+// The filter only matches a poll without the voter, so a vote, which
+// matches nothing, is looked up again to tell why.
+func (s *Service) AddVote(ctx context.Context, pollId primitive.ObjectID, voterId primitive.ObjectID, position int) (*model.Poll, error) {
+	option := "options." + strconv.Itoa(position)
+	pollFilter := bson.M{"_id": pollId, "voter_ids": bson.M{"$ne": voterId}, option: bson.M{"$exists": true}}
+	update := bson.M{
+		"$push": bson.M{"voter_ids": voterId},
+		"$inc":  bson.M{option + ".count": 1},
+		"$set":  bson.M{"last_modified": primitive.NewDateTimeFromTime(time.Now().UTC())},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	result := s.pollCollection().FindOneAndDelete(ctx, poll)
+	updateOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	result := s.pollCollection().FindOneAndUpdate(ctx, pollFilter, update, updateOptions)
 	err := result.Err()
+	if err == mongo.ErrNoDocuments {
+		return nil, s.missedVote(ctx, pollId, voterId)
+	}
+
 	if err != nil {
-		return err
+		return nil, storage.FromMongo(err, nil)
 	}
 
-	return nil
-}
+	var poll *model.Poll
+	err = result.Decode(&poll)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *Service) pollCollection() *mongo.Collection {
-	return s.client.Database("survey").Collection("poll")
+	return poll, nil
 }
 
-func (s *Service) createPollIndexes() error {
-	collection := s.pollCollection()
-	indexes := []mongo.IndexModel{
-		{
-			Keys: bson.M{"content": "text"},
-		},
+func (s *Service) missedVote(ctx context.Context, pollId primitive.ObjectID, voterId primitive.ObjectID) error {
+	voted, err := s.pollCollection().CountDocuments(ctx, bson.M{"_id": pollId, "voter_ids": voterId})
+	if err != nil {
+		return storage.FromMongo(err, nil)
 	}
 
-	context, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if voted != 0 {
+		return &storage.DuplicateError{Field: "voter_id"}
+	}
+
+	return storage.ErrNotFound
+}
+
+func (s *Service) DeleteOne(ctx context.Context, poll *model.Poll) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	_, err := collection.Indexes().CreateMany(context, indexes)
+	result := s.pollCollection().FindOneAndDelete(ctx, poll)
+	err := result.Err()
 	if err != nil {
-		return err
+		return storage.FromMongo(err, nil)
 	}
 
 	return nil
 }
+
+func (s *Service) pollCollection() *mongo.Collection {
+	return s.db.Collection("poll")
+}
@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"context"
+	"survey-api/pkg/mongodb/mongodbtest"
+	"survey-api/pkg/poll/model"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOperationsFollowContext(t *testing.T) {
+	timeout := 50 * time.Millisecond
+	s := &Service{db: mongodbtest.Unreachable(t), timeout: timeout}
+	poll := &model.Poll{Id: primitive.NewObjectID(), OwnerId: primitive.NewObjectID()}
+	mongodbtest.TestCancellation(t, timeout, map[string]mongodbtest.Operation{
+		"InsertOne": func(ctx context.Context) error {
+			_, err := s.InsertOne(ctx, poll)
+			return err
+		},
+		"FindById": func(ctx context.Context) error {
+			_, err := s.FindById(ctx, poll.Id.Hex())
+			return err
+		},
+		"FindOne": func(ctx context.Context) error {
+			_, err := s.FindOne(ctx, &model.Poll{Id: poll.Id})
+			return err
+		},
+		"UpdateOne": func(ctx context.Context) error {
+			_, err := s.UpdateOne(ctx, poll)
+			return err
+		},
+		"AddVote": func(ctx context.Context) error {
+			_, err := s.AddVote(ctx, poll.Id, primitive.NewObjectID(), 0)
+			return err
+		},
+		"DeleteOne": func(ctx context.Context) error {
+			return s.DeleteOne(ctx, poll)
+		},
+	})
+}
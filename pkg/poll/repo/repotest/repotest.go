@@ -0,0 +1,248 @@
+// Package repotest is the contract every implementation of the poll
+// repository has to satisfy.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"survey-api/pkg/poll/model"
+	"survey-api/pkg/poll/repo"
+	"survey-api/pkg/storage"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Storage is a new, empty repository for a test. Polls and their votes reference
+// users, so NewUserId stores a user next to the repository and returns its id.
+type Storage struct {
+	repo.Repository
+	NewUserId func(t *testing.T) primitive.ObjectID
+}
+
+// AnyUserId is the NewUserId of storages, which do not check references.
+func AnyUserId(t *testing.T) primitive.ObjectID {
+	return primitive.NewObjectID()
+}
+
+// Run runs the contract against a new storage for every test.
+func Run(t *testing.T, newStorage func(t *testing.T) *Storage) {
+	tests := map[string]func(t *testing.T, r *Storage){
+		"InsertAndFind":    testInsertAndFind,
+		"NotFound":         testNotFound,
+		"UpdateSetsFields": testUpdateSetsFields,
+		"UpdateNotFound":   testUpdateNotFound,
+		"AddVote":          testAddVote,
+		"ConcurrentVotes":  testConcurrentVotes,
+		"Delete":           testDelete,
+		"CancelledContext": testCancelledContext,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, newStorage(t))
+		})
+	}
+}
+
+func newPoll(t *testing.T, r *Storage) *model.Poll {
+	return &model.Poll{
+		Id:      primitive.NewObjectID(),
+		OwnerId: r.NewUserId(t),
+		Content: "Question?",
+		Options: []model.PollOption{
+			{Index: "0", Content: "Yes"},
+			{Index: "1", Content: "No"},
+		},
+		Visibility: model.Public,
+	}
+}
+
+func insert(t *testing.T, r *Storage, poll *model.Poll) {
+	t.Helper()
+	_, err := r.InsertOne(context.Background(), poll)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testInsertAndFind(t *testing.T, r *Storage) {
+	poll := newPoll(t, r)
+	insert(t, r, poll)
+
+	found, err := r.FindById(context.Background(), poll.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.Content != poll.Content || len(found.Options) != len(poll.Options) || found.LastModified == 0 {
+		t.Fatalf("expected %+v, got %+v", poll, found)
+	}
+
+	found.Options[0].Count++
+	found, err = r.FindOne(context.Background(), &model.Poll{Id: poll.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.Options[0].Count != 0 {
+		t.Fatal("expected the stored poll not to change with a returned one")
+	}
+}
+
+func testNotFound(t *testing.T, r *Storage) {
+	insert(t, r, newPoll(t, r))
+
+	_, err := r.FindById(context.Background(), primitive.NewObjectID().Hex())
+	if err != storage.ErrNotFound {
+		t.Fatalf("unknown id: expected %v, got %v", storage.ErrNotFound, err)
+	}
+
+	_, err = r.FindById(context.Background(), "invalid")
+	if err != storage.ErrNotFound {
+		t.Fatalf("invalid id: expected %v, got %v", storage.ErrNotFound, err)
+	}
+
+	_, err = r.FindById(context.Background(), primitive.NilObjectID.Hex())
+	if err != storage.ErrNotFound {
+		t.Fatalf("zero id: expected %v, got %v", storage.ErrNotFound, err)
+	}
+}
+
+func testUpdateSetsFields(t *testing.T, r *Storage) {
+	poll := newPoll(t, r)
+	insert(t, r, poll)
+
+	voterId := r.NewUserId(t)
+	poll.VoterIds = append(poll.VoterIds, voterId)
+	poll.Options[1].Count++
+	_, err := r.UpdateOne(context.Background(), poll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := r.FindById(context.Background(), poll.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(found.VoterIds) != 1 || found.VoterIds[0] != voterId || found.Options[1].Count != 1 {
+		t.Fatalf("expected the vote to be stored, got %+v", found)
+	}
+
+	if found.Content != poll.Content || found.OwnerId != poll.OwnerId {
+		t.Fatalf("expected the other fields to be kept, got %+v", found)
+	}
+}
+
+func testUpdateNotFound(t *testing.T, r *Storage) {
+	_, err := r.UpdateOne(context.Background(), newPoll(t, r))
+	if err != storage.ErrNotFound {
+		t.Fatalf("expected %v, got %v", storage.ErrNotFound, err)
+	}
+}
+
+func testAddVote(t *testing.T, r *Storage) {
+	poll := newPoll(t, r)
+	insert(t, r, poll)
+
+	voterId := r.NewUserId(t)
+	voted, err := r.AddVote(context.Background(), poll.Id, voterId, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(voted.VoterIds) != 1 || voted.VoterIds[0] != voterId || voted.Options[1].Count != 1 || voted.Options[0].Count != 0 {
+		t.Fatalf("expected the vote to be returned, got %+v", voted)
+	}
+
+	_, err = r.AddVote(context.Background(), poll.Id, voterId, 0)
+	if !errors.Is(err, storage.ErrDuplicate) {
+		t.Fatalf("second vote: expected %v, got %v", storage.ErrDuplicate, err)
+	}
+
+	_, err = r.AddVote(context.Background(), primitive.NewObjectID(), voterId, 0)
+	if err != storage.ErrNotFound {
+		t.Fatalf("unknown poll: expected %v, got %v", storage.ErrNotFound, err)
+	}
+
+	found, err := r.FindById(context.Background(), poll.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(found.VoterIds) != 1 || found.Options[0].Count != 0 || found.Options[1].Count != 1 {
+		t.Fatalf("expected only the first vote to be stored, got %+v", found)
+	}
+}
+
+func testConcurrentVotes(t *testing.T, r *Storage) {
+	poll := newPoll(t, r)
+	insert(t, r, poll)
+
+	voterIds := []primitive.ObjectID{r.NewUserId(t), r.NewUserId(t)}
+	errs := make(chan error, len(voterIds))
+	for _, voterId := range voterIds {
+		go func(voterId primitive.ObjectID) {
+			_, err := r.AddVote(context.Background(), poll.Id, voterId, 0)
+			errs <- err
+		}(voterId)
+	}
+
+	for range voterIds {
+		err := <-errs
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, err := r.FindById(context.Background(), poll.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(found.VoterIds) != 2 || found.Options[0].Count != 2 {
+		t.Fatalf("expected both votes to be counted, got %+v", found)
+	}
+}
+
+func testDelete(t *testing.T, r *Storage) {
+	poll := newPoll(t, r)
+	other := newPoll(t, r)
+	insert(t, r, poll)
+	insert(t, r, other)
+
+	found, err := r.FindById(context.Background(), poll.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.DeleteOne(context.Background(), found)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.FindById(context.Background(), poll.Id.Hex())
+	if err != storage.ErrNotFound {
+		t.Fatalf("expected %v, got %v", storage.ErrNotFound, err)
+	}
+
+	_, err = r.FindById(context.Background(), other.Id.Hex())
+	if err != nil {
+		t.Fatalf("expected the other poll to be kept, got %v", err)
+	}
+
+	err = r.DeleteOne(context.Background(), found)
+	if err != storage.ErrNotFound {
+		t.Fatalf("expected %v, got %v", storage.ErrNotFound, err)
+	}
+}
+
+func testCancelledContext(t *testing.T, r *Storage) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := r.InsertOne(ctx, newPoll(t, r))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Params numbers the arguments of a statement, which is built from the
+// non-zero fields of a model.
+type Params struct {
+	args []interface{}
+}
+
+// Add adds an argument and returns its placeholder.
+func (p *Params) Add(arg interface{}) string {
+	p.args = append(p.args, arg)
+	return "$" + strconv.Itoa(len(p.args))
+}
+
+func (p *Params) Args() []interface{} {
+	return p.args
+}
+
+// Where joins the conditions into a WHERE clause, which is empty without
+// conditions, so that it matches every row like an empty MongoDB filter.
+func Where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// ObjectID parses an id column, which holds the hex string of an ObjectID.
+func ObjectID(hex string) (primitive.ObjectID, error) {
+	return primitive.ObjectIDFromHex(strings.TrimSpace(hex))
+}
+
+// NullTime converts a zero DateTime into NULL.
+func NullTime(dateTime primitive.DateTime) interface{} {
+	if dateTime == 0 {
+		return nil
+	}
+
+	return dateTime.Time().UTC()
+}
+
+// DateTime converts a nullable column back into a DateTime, which is zero
+// for NULL.
+func DateTime(value *time.Time) primitive.DateTime {
+	if value == nil {
+		return 0
+	}
+
+	return primitive.NewDateTimeFromTime(*value)
+}
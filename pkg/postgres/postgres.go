@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"survey-api/pkg/config"
+	"survey-api/pkg/storage"
+	"time"
+
+	// Registers the postgres driver with database/sql.
+	_ "github.com/lib/pq"
+)
+
+const (
+	driverName            = "postgres"
+	connectAttempts       = 3
+	initialConnectBackoff = 250 * time.Millisecond
+	connectTimeout        = 2 * time.Second
+)
+
+// NewDB opens the connection pool and waits until the server answers, see
+// WaitReady.
+func NewDB(config *config.Config) (*sql.DB, error) {
+	db, err := OpenDB(config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = WaitReady(context.Background(), db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// OpenDB opens the connection pool without waiting for the server. The
+// connections are only made by the first statements, which fail with
+// storage.ErrUnavailable while the server cannot be reached.
+func OpenDB(config *config.Config) (*sql.DB, error) {
+	db, err := sql.Open(driverName, config.Postgres.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(config.Postgres.MaxOpenConns)
+	if config.Postgres.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(config.Postgres.MaxIdleConns)
+	}
+
+	db.SetConnMaxLifetime(config.Postgres.ConnMaxLifetime)
+	return db, nil
+}
+
+// WaitReady pings the server with a few retries and exponential backoff in
+// between, until it answers or ctx ends. A server, which cannot be
+// reached, is reported as storage.ErrUnavailable.
+func WaitReady(ctx context.Context, db *sql.DB) error {
+	backoff := initialConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err := ping(ctx, db)
+		if err == nil {
+			return nil
+		}
+
+		if attempt == connectAttempts {
+			return &storage.UnavailableError{Err: err}
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return &storage.UnavailableError{Err: err}
+		}
+
+		backoff *= 2
+	}
+}
+
+func ping(ctx context.Context, db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+	return db.PingContext(ctx)
+}
+
+// ExpectRows reports a statement, which changed no rows, with
+// storage.ErrNotFound.
+func ExpectRows(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
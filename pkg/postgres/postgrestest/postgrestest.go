@@ -0,0 +1,90 @@
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"survey-api/pkg/config"
+	"survey-api/pkg/migration"
+	"survey-api/pkg/postgres"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	urlEnv = "POSTGRES_TEST_URL"
+)
+
+// DB returns a connection to a new schema with every migration applied,
+// which is dropped after the test. The test is skipped unless
+// POSTGRES_TEST_URL names a database to create it in.
+func DB(t *testing.T) *sql.DB {
+	serverUrl := os.Getenv(urlEnv)
+	if len(serverUrl) == 0 {
+		t.Skip(urlEnv + " is not set")
+	}
+
+	admin := open(t, serverUrl)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	schema := "survey_test_" + primitive.NewObjectID().Hex()
+	_, err := admin.ExecContext(ctx, "CREATE SCHEMA "+schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+	})
+
+	// Every connection of the pool starts in the new schema.
+	schemaUrl, err := url.Parse(serverUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := schemaUrl.Query()
+	query.Set("search_path", schema)
+	schemaUrl.RawQuery = query.Encode()
+	db := open(t, schemaUrl.String())
+	_, err = migration.NewPostgres(db).Up(ctx, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// UserIds returns a function, which stores a new user and returns its id,
+// for the rows, which reference users.
+func UserIds(db *sql.DB) func(t *testing.T) primitive.ObjectID {
+	return func(t *testing.T) primitive.ObjectID {
+		t.Helper()
+		userId := primitive.NewObjectID()
+		_, err := db.Exec("INSERT INTO users (id, first_name, user_name, email, password) VALUES ($1, 'First', $2, $3, 'hash')",
+			userId.Hex(), userId.Hex(), userId.Hex()+"@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return userId
+	}
+}
+
+func open(t *testing.T, url string) *sql.DB {
+	conf := config.Default()
+	conf.Postgres.Url = url
+	db, err := postgres.NewDB(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// How often the full buckets are forgotten.
+	pruneInterval = time.Minute
+)
+
+// Memory keeps the buckets of a single instance. It is safe for concurrent
+// use.
+type Memory struct {
+	mutex      sync.Mutex
+	buckets    map[string]*memoryBucket
+	lastPruned time.Time
+}
+
+type memoryBucket struct {
+	bucket
+	expires time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*memoryBucket)}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.prune(now)
+
+	current, ok := m.buckets[key]
+	if !ok {
+		current = &memoryBucket{bucket: fullBucket(limit, now)}
+		m.buckets[key] = current
+	}
+
+	var result *Result
+	current.bucket, result = take(current.bucket, limit, now)
+	current.expires = expiry(current.bucket, limit)
+	return result, nil
+}
+
+func (m *Memory) prune(now time.Time) {
+	if now.Sub(m.lastPruned) < pruneInterval {
+		return
+	}
+
+	for key, bucket := range m.buckets {
+		if !now.Before(bucket.expires) {
+			delete(m.buckets, key)
+		}
+	}
+
+	m.lastPruned = now
+}
@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"survey-api/pkg/config"
+	"survey-api/pkg/storage"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// Concurrent requests of a client may update its bucket in between,
+	// in which case the request reads it again.
+	maxAttempts = 5
+)
+
+var (
+	ErrContended = errors.New("Rate limit bucket is updated concurrently")
+)
+
+// Mongo keeps the buckets in MongoDB, so that every instance shares them.
+// Full buckets expire through the TTL index on expires.
+type Mongo struct {
+	db      *mongo.Database
+	timeout time.Duration
+}
+
+type mongoBucket struct {
+	Key     string             `bson:"_id"`
+	Tokens  float64            `bson:"tokens"`
+	Updated primitive.DateTime `bson:"updated"`
+	Expires primitive.DateTime `bson:"expires"`
+	// Incremented by every update, which is only applied when the bucket
+	// is still at the version that was read.
+	Version int64 `bson:"version"`
+}
+
+func NewMongo(db *mongo.Database, config *config.Config) *Mongo {
+	return &Mongo{db: db, timeout: config.Mongodb.OperationTimeout}
+}
+
+func (m *Mongo) Take(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		result, err := m.take(ctx, key, limit, now)
+		if err != ErrContended {
+			return result, err
+		}
+	}
+
+	return nil, ErrContended
+}
+
+func (m *Mongo) take(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error) {
+	stored := &mongoBucket{}
+	err := m.rateLimitCollection().FindOne(ctx, bson.M{"_id": key}).Decode(stored)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, storage.FromMongo(err, nil)
+	}
+
+	found := err == nil
+	current := fullBucket(limit, now)
+	if found {
+		current = bucket{Tokens: stored.Tokens, Updated: stored.Updated.Time()}
+	}
+
+	current, result := take(current, limit, now)
+	if !result.Allowed {
+		// Nothing was taken, so the bucket is left as it is.
+		return result, nil
+	}
+
+	updated := &mongoBucket{
+		Key:     key,
+		Tokens:  current.Tokens,
+		Updated: primitive.NewDateTimeFromTime(current.Updated),
+		Expires: primitive.NewDateTimeFromTime(expiry(current, limit)),
+	}
+	if !found {
+		_, err = m.rateLimitCollection().InsertOne(ctx, updated)
+		err = storage.FromMongo(err, nil)
+		if errors.Is(err, storage.ErrDuplicate) {
+			return nil, ErrContended
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	}
+
+	updated.Version = stored.Version + 1
+	replaced, err := m.rateLimitCollection().ReplaceOne(ctx, bson.M{"_id": key, "version": stored.Version}, updated)
+	if err != nil {
+		return nil, storage.FromMongo(err, nil)
+	}
+
+	if replaced.MatchedCount == 0 {
+		return nil, ErrContended
+	}
+
+	return result, nil
+}
+
+func (m *Mongo) rateLimitCollection() *mongo.Collection {
+	return m.db.Collection("rate_limit")
+}
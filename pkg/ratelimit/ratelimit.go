@@ -0,0 +1,120 @@
+// Package ratelimit limits the requests of every client to the routes with
+// a configured limit, with a token bucket per route and client.
+//
+// A bucket holds up to the number of requests of the limit and is refilled
+// evenly over its period, so that a client can send short bursts, but not
+// more than the limit on average.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"survey-api/pkg/config"
+	"survey-api/pkg/router"
+	"time"
+)
+
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Result is the state of a bucket after a request took a token from it, or
+// failed to.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// The time until the next token, when the request was not allowed.
+	RetryAfter time.Duration
+	// The time until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps the buckets. Take must be atomic for every key, also between
+// instances sharing the store.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error)
+}
+
+type Service struct {
+	store  Store
+	limits map[router.Route]Limit
+	now    func() time.Time
+}
+
+// New creates the limiter of the RATE_LIMITS, which are validated by the
+// configuration.
+func New(c *config.Config, store Store) (*Service, error) {
+	routeLimits, err := c.RateLimit.RouteLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make(map[router.Route]Limit, len(routeLimits))
+	for _, routeLimit := range routeLimits {
+		route := router.Route{Method: routeLimit.Method, Pattern: routeLimit.Pattern}
+		limits[route] = Limit{Requests: routeLimit.Requests, Period: routeLimit.Period}
+	}
+
+	return &Service{store: store, limits: limits, now: time.Now}, nil
+}
+
+// Limits reports whether the requests to the route are limited.
+func (s *Service) Limits(route router.Route) bool {
+	_, ok := s.limits[route]
+	return ok
+}
+
+// Take takes a token from the bucket of the client for the route. Routes
+// without a limit always allow the request.
+func (s *Service) Take(ctx context.Context, route router.Route, client string) (*Result, error) {
+	limit, ok := s.limits[route]
+	if !ok {
+		return &Result{Allowed: true}, nil
+	}
+
+	return s.store.Take(ctx, route.Method+" "+route.Pattern+" "+client, limit, s.now())
+}
+
+// bucket is the state of a bucket, which was last refilled at Updated.
+type bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// fullBucket is the bucket of a client without any recent request.
+func fullBucket(limit Limit, now time.Time) bucket {
+	return bucket{Tokens: float64(limit.Requests), Updated: now}
+}
+
+// take refills the bucket up to now and takes a token from it, if there is
+// one.
+func take(b bucket, limit Limit, now time.Time) (bucket, *Result) {
+	perToken := limit.Period / time.Duration(limit.Requests)
+	elapsed := now.Sub(b.Updated)
+	if elapsed < 0 {
+		// The clocks of the instances sharing a store may differ.
+		elapsed = 0
+	}
+
+	b.Tokens = math.Min(float64(limit.Requests), b.Tokens+float64(elapsed)/float64(perToken))
+	b.Updated = now
+	result := &Result{Allowed: b.Tokens >= 1, Limit: limit.Requests}
+	if result.Allowed {
+		b.Tokens--
+	} else {
+		result.RetryAfter = time.Duration((1 - b.Tokens) * float64(perToken))
+	}
+
+	result.Remaining = int(b.Tokens)
+	result.Reset = time.Duration((float64(limit.Requests) - b.Tokens) * float64(perToken))
+	return b, result
+}
+
+// expiry returns when the bucket is full again, so that it can be
+// forgotten.
+func expiry(b bucket, limit Limit) time.Time {
+	perToken := limit.Period / time.Duration(limit.Requests)
+	return b.Updated.Add(time.Duration((float64(limit.Requests) - b.Tokens) * float64(perToken)))
+}
@@ -0,0 +1,64 @@
+package ratelimit_test
+
+import (
+	"context"
+	"survey-api/pkg/config"
+	"survey-api/pkg/mongodb/mongodbtest"
+	"survey-api/pkg/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	runStore(t, ratelimit.NewMemory())
+}
+
+func TestMongo(t *testing.T) {
+	runStore(t, ratelimit.NewMongo(mongodbtest.Database(t), config.Default()))
+}
+
+// Mongo keeps the time in milliseconds, so the times are whole seconds.
+func runStore(t *testing.T, store ratelimit.Store) {
+	ctx := context.Background()
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+	start := time.Now().Truncate(time.Second)
+	take := func(key string, at time.Duration) *ratelimit.Result {
+		t.Helper()
+		result, err := store.Take(ctx, key, limit, start.Add(at))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return result
+	}
+
+	tests := []struct {
+		name      string
+		key       string
+		at        time.Duration
+		allowed   bool
+		remaining int
+		reset     time.Duration
+	}{
+		{"first request", "alice", 0, true, 1, 30 * time.Second},
+		{"burst", "alice", 0, true, 0, time.Minute},
+		{"exhausted", "alice", 10 * time.Second, false, 0, 50 * time.Second},
+		{"other key", "bob", 10 * time.Second, true, 1, 30 * time.Second},
+		{"refilled", "alice", 30 * time.Second, true, 0, time.Minute},
+		{"full again", "alice", 5 * time.Minute, true, 1, 30 * time.Second},
+	}
+	for _, test := range tests {
+		result := take(test.key, test.at)
+		if result.Allowed != test.allowed || result.Remaining != test.remaining || result.Reset != test.reset {
+			t.Errorf("%s: expected allowed %t, remaining %d and reset %s, got %+v",
+				test.name, test.allowed, test.remaining, test.reset, result)
+		}
+	}
+
+	take("carol", 0)
+	take("carol", 0)
+	result := take("carol", 20*time.Second)
+	if result.Allowed || result.RetryAfter != 10*time.Second || result.Limit != 2 {
+		t.Errorf("expected to retry after 10s, got %+v", result)
+	}
+}
@@ -0,0 +1,61 @@
+// Package requestid ties the logs, the error responses and the audit
+// records of a request to the X-Request-ID header, which the client sent or
+// which was generated for it.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	Header = "X-Request-ID"
+
+	generatedBytes = 16
+	maxLength      = 128
+)
+
+type contextKey int
+
+const (
+	requestIdKey contextKey = iota
+)
+
+// New generates a random id.
+func New() string {
+	id := make([]byte, generatedBytes)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Valid reports whether an id sent by a client can be kept. The id ends up
+// in the logs, so only short ids of letters, digits and the separators of
+// UUIDs and similar formats are kept.
+func Valid(id string) bool {
+	if len(id) == 0 || len(id) > maxLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey, id)
+}
+
+// FromContext returns the id of the request, or an empty string outside of
+// a request.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey).(string)
+	return id
+}
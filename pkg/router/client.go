@@ -0,0 +1,35 @@
+package router
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// ClientIp returns the address of the client, as kept by WithClientIp, or
+// else the address of the peer of the connection, without its port.
+func ClientIp(r *http.Request) string {
+	ip, ok := r.Context().Value(clientIpKey).(string)
+	if ok {
+		return ip
+	}
+
+	return PeerIp(r)
+}
+
+// WithClientIp keeps the address of the client, which a proxy forwarded,
+// for ClientIp.
+func WithClientIp(r *http.Request, ip string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), clientIpKey, ip))
+}
+
+// PeerIp returns the address of the peer of the connection, without its
+// port.
+func PeerIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,42 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"survey-api/pkg/apperror"
+)
+
+const (
+	jsonContentType = "application/json"
+)
+
+func DecodeJSON(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	// Returned by the body of the MaxBodySize middleware.
+	if errors.Is(err, apperror.ErrBodyTooLarge) {
+		return apperror.ErrBodyTooLarge
+	}
+
+	if err != nil {
+		return &apperror.Error{
+			Kind:    apperror.KindBadRequest,
+			Message: apperror.ErrMalformedBody.Message,
+			Err:     err,
+		}
+	}
+
+	return nil
+}
+
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) error {
+	result, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(status)
+	w.Write(result)
+	return nil
+}
@@ -0,0 +1,220 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"survey-api/pkg/apperror"
+	"survey-api/pkg/logger"
+)
+
+const (
+	paramsKey contextKey = iota
+	matchedKey
+	clientIpKey
+)
+
+type contextKey int
+
+// HandlerFunc handles a single route. A returned error is logged and
+// written to the client as a problem, so handlers only write successful
+// responses.
+type HandlerFunc func(http.ResponseWriter, *http.Request) error
+
+type Middleware func(http.Handler) http.Handler
+
+type Route struct {
+	Method  string
+	Pattern string
+}
+
+type Router struct {
+	logger     *logger.Service
+	routes     []*route
+	middleware []Middleware
+}
+
+// The route of a request, which is matched before the middleware of the
+// router runs. Route is nil, when the path matched no route for the method
+// of the request, and allowed holds the methods of the path, if any.
+type match struct {
+	route   *route
+	params  map[string]string
+	allowed []string
+}
+
+type route struct {
+	Route
+	segments []string
+	handler  http.Handler
+}
+
+func New(logger *logger.Service) *Router {
+	return &Router{logger: logger}
+}
+
+// Use adds middleware, which runs for every request, even when it matches
+// no route.
+func (rt *Router) Use(middleware ...Middleware) {
+	rt.middleware = append(rt.middleware, middleware...)
+}
+
+// Handle registers a handler for the method and the pattern. Segments of
+// the pattern in braces, like "/poll/{id}", match any value, which is then
+// available through Param. The middleware only runs for this route.
+func (rt *Router) Handle(method string, pattern string, handler HandlerFunc, middleware ...Middleware) {
+	rt.routes = append(rt.routes, &route{
+		Route:    Route{Method: method, Pattern: pattern},
+		segments: splitPath(pattern),
+		handler:  Chain(rt.handleErrors(handler), middleware...),
+	})
+}
+
+func (rt *Router) Routes() []Route {
+	routes := make([]Route, len(rt.routes))
+	for i, route := range rt.routes {
+		routes[i] = route.Route
+	}
+
+	return routes
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), matchedKey, rt.find(r))
+	Chain(http.HandlerFunc(rt.dispatch), rt.middleware...).ServeHTTP(w, r.WithContext(ctx))
+}
+
+func Param(r *http.Request, name string) string {
+	params, _ := r.Context().Value(paramsKey).(map[string]string)
+	return params[name]
+}
+
+// MatchedRoute returns the route, which serves the request. It is already
+// known to the middleware of the router. It returns false for requests,
+// which match no route.
+func MatchedRoute(r *http.Request) (Route, bool) {
+	matched, _ := r.Context().Value(matchedKey).(*match)
+	if matched == nil || matched.route == nil {
+		return Route{}, false
+	}
+
+	return matched.route.Route, true
+}
+
+// Chain wraps the handler with the middleware, the first one being the
+// outermost.
+func Chain(handler http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+
+	return handler
+}
+
+// Routes with more static segments take precedence, so that "/poll/vote"
+// is never matched by "/poll/{id}".
+func (rt *Router) find(r *http.Request) *match {
+	segments := splitPath(r.URL.Path)
+	found := &match{}
+	matchedStatic := -1
+	for _, route := range rt.routes {
+		params, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+
+		static := len(segments) - len(params)
+		if static < matchedStatic {
+			continue
+		}
+
+		if static > matchedStatic {
+			matchedStatic = static
+			found = &match{}
+		}
+
+		found.allowed = appendUnique(found.allowed, route.Method)
+		if found.route == nil && route.Method == r.Method {
+			found.route = route
+			found.params = params
+		}
+	}
+
+	return found
+}
+
+func (rt *Router) dispatch(w http.ResponseWriter, r *http.Request) {
+	matched, _ := r.Context().Value(matchedKey).(*match)
+	if matched.route != nil {
+		ctx := context.WithValue(r.Context(), paramsKey, matched.params)
+		matched.route.handler.ServeHTTP(w, r.WithContext(ctx))
+		return
+	}
+
+	if len(matched.allowed) != 0 {
+		w.Header().Set("Allow", strings.Join(matched.allowed, ", "))
+		apperror.Write(w, r, apperror.ErrMethodNotAllowed)
+		return
+	}
+
+	apperror.Write(w, r, apperror.ErrResourceNotFound)
+}
+
+func (rt *Router) handleErrors(handler HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := handler(w, r)
+		if err == nil {
+			return
+		}
+
+		// Only server errors are logged as errors, the others are caused by
+		// the client.
+		fields := logger.Fields{"error": err}
+		if apperror.StatusCode(err) >= http.StatusInternalServerError {
+			rt.logger.For(r.Context()).Error("Request failed", fields)
+		} else {
+			rt.logger.For(r.Context()).Info("Request rejected", fields)
+		}
+
+		apperror.Write(w, r, err)
+	})
+}
+
+func (r *route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params[segment[1:len(segment)-1]] = segments[i]
+			continue
+		}
+
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if len(path) == 0 {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, item := range values {
+		if item == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+}
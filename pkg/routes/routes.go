@@ -0,0 +1,170 @@
+// Package routes is the authoritative route table of the application.
+//
+// The standalone server registers every endpoint from the table, and
+// now.json, which deploys every other endpoint as a serverless function,
+// is generated from it by cmd/routes.
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	auditapi "survey-api/pkg/audit/api"
+	"survey-api/pkg/auth/api/login"
+	"survey-api/pkg/auth/api/logout"
+	"survey-api/pkg/auth/api/refresh"
+	"survey-api/pkg/auth/api/register"
+	"survey-api/pkg/config"
+	"survey-api/pkg/di"
+	"survey-api/pkg/endpoint"
+	healthapi "survey-api/pkg/health/api"
+	metricsapi "survey-api/pkg/metrics/api"
+	pollapi "survey-api/pkg/poll/api"
+	pollvote "survey-api/pkg/poll/api/vote"
+	"survey-api/pkg/router"
+)
+
+const (
+	nowVersion = 2
+	nowBuilder = "@now/go"
+)
+
+type Endpoint struct {
+	// The file, which exports the entry point of the serverless function,
+	// relative to the root of the repository.
+	Source string
+	Routes endpoint.Routes
+	// StandaloneOnly endpoints are only served by the standalone server,
+	// and are not deployed as a serverless function.
+	StandaloneOnly bool
+}
+
+var Endpoints = []Endpoint{
+	{Source: "pkg/auth/api/register/register.go", Routes: register.Routes},
+	{Source: "pkg/auth/api/login/login.go", Routes: login.Routes},
+	{Source: "pkg/auth/api/logout/logout.go", Routes: logout.Routes},
+	{Source: "pkg/auth/api/refresh/refresh.go", Routes: refresh.Routes},
+	{Source: "pkg/poll/api/api.go", Routes: pollapi.Routes},
+	{Source: "pkg/poll/api/vote/vote.go", Routes: pollvote.Routes},
+	{Source: "pkg/health/api/api.go", Routes: healthapi.Routes},
+	{Routes: metricsapi.Routes, StandaloneOnly: true},
+	{Source: "pkg/audit/api/api.go", Routes: auditapi.Routes},
+}
+
+type NowConfig struct {
+	Version int        `json:"version"`
+	Public  bool       `json:"public"`
+	Builds  []NowBuild `json:"builds"`
+	Routes  []NowRoute `json:"routes"`
+}
+
+type NowBuild struct {
+	Src string `json:"src"`
+	Use string `json:"use"`
+}
+
+type NowRoute struct {
+	Src  string `json:"src"`
+	Dest string `json:"dest"`
+}
+
+// Register registers the routes of every endpoint on the router.
+func Register(rt *router.Router, container *di.Dependencies) {
+	for _, endpoint := range Endpoints {
+		endpoint.Routes(rt, container)
+	}
+}
+
+// CheckRateLimits reports the rate limits of routes, which are not in the
+// table, since a mistyped route would be left unlimited without a notice.
+// A serverless function only knows its own routes, so the limits are
+// checked by the standalone server and by the tests of the default limits.
+func CheckRateLimits(c *config.RateLimit) error {
+	limits, err := c.RouteLimits()
+	if err != nil {
+		return err
+	}
+
+	rt := router.New(nil)
+	Register(rt, &di.Dependencies{})
+	served := make(map[router.Route]bool)
+	for _, route := range rt.Routes() {
+		served[route] = true
+	}
+
+	var unknown []string
+	for _, limit := range limits {
+		if !served[router.Route{Method: limit.Method, Pattern: limit.Pattern}] {
+			unknown = append(unknown, limit.Method+" "+limit.Pattern)
+		}
+	}
+
+	if len(unknown) != 0 {
+		return errors.New("RATE_LIMITS limits unknown routes: " + strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// Patterns returns the distinct path patterns of an endpoint. The routes
+// are registered with empty dependencies, which are never called.
+func (e *Endpoint) Patterns() []string {
+	rt := router.New(nil)
+	e.Routes(rt, &di.Dependencies{})
+	var patterns []string
+	seen := make(map[string]bool)
+	for _, route := range rt.Routes() {
+		if seen[route.Pattern] {
+			continue
+		}
+
+		seen[route.Pattern] = true
+		patterns = append(patterns, route.Pattern)
+	}
+
+	return patterns
+}
+
+// Now generates the deployment configuration. The methods are left to the
+// router, so that it can respond with 405 instead of the host's 404.
+func Now() *NowConfig {
+	config := &NowConfig{Version: nowVersion}
+	for _, endpoint := range Endpoints {
+		if endpoint.StandaloneOnly {
+			continue
+		}
+
+		dest := "/" + endpoint.Source
+		config.Builds = append(config.Builds, NowBuild{Src: dest, Use: nowBuilder})
+		for _, pattern := range endpoint.Patterns() {
+			config.Routes = append(config.Routes, NowRoute{Src: patternToRegexp(pattern), Dest: dest})
+		}
+	}
+
+	// The host uses the first matching route, static paths must come first.
+	sort.SliceStable(config.Routes, func(i, j int) bool {
+		return strings.Count(config.Routes[i].Src, "[^/]+") < strings.Count(config.Routes[j].Src, "[^/]+")
+	})
+	return config
+}
+
+func MarshalNow() ([]byte, error) {
+	result, err := json.MarshalIndent(Now(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(result, '\n'), nil
+}
+
+func patternToRegexp(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = "[^/]+"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
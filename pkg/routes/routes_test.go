@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"survey-api/pkg/config"
+	"survey-api/pkg/di"
+	"survey-api/pkg/router"
+	"testing"
+)
+
+const (
+	root = "../.."
+)
+
+func TestNowIsUpToDate(t *testing.T) {
+	current, err := ioutil.ReadFile(filepath.Join(root, "now.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := MarshalNow()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(current, generated) {
+		t.Fatal("now.json is out of date, run: go run ./cmd/routes")
+	}
+}
+
+func TestDeployedRoutesMatchServerRoutes(t *testing.T) {
+	current, err := ioutil.ReadFile(filepath.Join(root, "now.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config NowConfig
+	err = json.Unmarshal(current, &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deployed []string
+	for _, route := range config.Routes {
+		deployed = append(deployed, route.Src)
+	}
+
+	rt := router.New(nil)
+	Register(rt, &di.Dependencies{})
+	standalone := make(map[string]bool)
+	for _, endpoint := range Endpoints {
+		if endpoint.StandaloneOnly {
+			for _, pattern := range endpoint.Patterns() {
+				standalone[pattern] = true
+			}
+		}
+	}
+
+	var served []string
+	seen := make(map[string]bool)
+	for _, route := range rt.Routes() {
+		src := patternToRegexp(route.Pattern)
+		if !seen[src] && !standalone[route.Pattern] {
+			seen[src] = true
+			served = append(served, src)
+		}
+	}
+
+	sort.Strings(deployed)
+	sort.Strings(served)
+	if strings.Join(deployed, " ") != strings.Join(served, " ") {
+		t.Fatalf("deployed routes %v, served routes %v", deployed, served)
+	}
+}
+
+func TestDefaultRateLimitsMatchRoutes(t *testing.T) {
+	err := CheckRateLimits(&config.Default().RateLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = CheckRateLimits(&config.RateLimit{Limits: "POST /register=5/1m, GET /register=5/1m, POST /polls=5/1m"})
+	if err == nil || err.Error() != "RATE_LIMITS limits unknown routes: GET /register, POST /polls" {
+		t.Errorf("expected the unknown routes to be reported, got %v", err)
+	}
+}
+
+func TestEndpointsExportHandler(t *testing.T) {
+	for _, endpoint := range Endpoints {
+		if endpoint.StandaloneOnly {
+			continue
+		}
+
+		source, err := ioutil.ReadFile(filepath.Join(root, endpoint.Source))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(string(source), "func Handler(w http.ResponseWriter, r *http.Request)") {
+			t.Errorf("%s does not export the serverless entry point", endpoint.Source)
+		}
+	}
+}
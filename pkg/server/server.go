@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"survey-api/pkg/config"
+	"syscall"
+	"time"
+)
+
+const (
+	// The time left to onShutdown, when the requests used up the
+	// shutdown timeout.
+	releaseTimeout = 5 * time.Second
+)
+
+// Run serves the handler until the process receives SIGTERM or SIGINT.
+// It then stops accepting connections, waits for the in-flight requests
+// to finish and calls onShutdown with the remaining time, to release
+// resources like database connections. onShutdown is called even when the
+// requests did not finish in time.
+func Run(config *config.Server, handler http.Handler, onShutdown func(context.Context) error) error {
+	server := &http.Server{
+		Addr:              config.Host + ":" + config.Port,
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if len(config.TLSCertFile) != 0 {
+			serveErr <- server.ListenAndServeTLS(config.TLSCertFile, config.TLSKeyFile)
+			return
+		}
+
+		serveErr <- server.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(signals)
+	select {
+	case err := <-serveErr:
+		return err
+	case <-signals:
+	}
+
+	return shutdown(server, config.ShutdownTimeout, onShutdown)
+}
+
+// shutdown returns the first error of the server and onShutdown.
+func shutdown(server *http.Server, timeout time.Duration, onShutdown func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := server.Shutdown(ctx)
+	if onShutdown == nil {
+		return err
+	}
+
+	releaseCtx := ctx
+	if ctx.Err() != nil {
+		var releaseCancel context.CancelFunc
+		releaseCtx, releaseCancel = context.WithTimeout(context.Background(), releaseTimeout)
+		defer releaseCancel()
+	}
+
+	releaseErr := onShutdown(releaseCtx)
+	if err != nil {
+		return err
+	}
+
+	return releaseErr
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShutdownReleasesAfterTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+	go server.Serve(listener)
+	go http.Get("http://" + listener.Addr().String())
+	<-started
+
+	released := false
+	err = shutdown(server, 10*time.Millisecond, func(ctx context.Context) error {
+		released = ctx.Err() == nil
+		return nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected the shutdown to time out, got %v", err)
+	}
+
+	if !released {
+		t.Error("expected onShutdown to be called with a live context")
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// Gate holds the requests back until the storage is ready, as checked by
+// open, which may retry. Only a successful check is kept. After a failed
+// one, the next request checks again.
+type Gate struct {
+	open    func(ctx context.Context) error
+	mutex   sync.Mutex
+	ready   bool
+	err     error
+	attempt chan struct{}
+}
+
+// NewGate creates a gate, which is ready right away when open is nil.
+func NewGate(open func(ctx context.Context) error) *Gate {
+	return &Gate{open: open, ready: open == nil}
+}
+
+// Wait returns once the storage is ready, or with the error of the check.
+// Concurrent requests share one check, and the mutex is only held to join
+// it, so that requests, which give up, do not wait for it.
+func (g *Gate) Wait(ctx context.Context) error {
+	g.mutex.Lock()
+	if g.ready {
+		g.mutex.Unlock()
+		return nil
+	}
+
+	attempt := g.attempt
+	if attempt == nil {
+		attempt = make(chan struct{})
+		g.attempt = attempt
+		g.mutex.Unlock()
+		g.check(ctx, attempt)
+	} else {
+		g.mutex.Unlock()
+	}
+
+	select {
+	case <-attempt:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.ready {
+		return nil
+	}
+
+	return g.err
+}
+
+func (g *Gate) check(ctx context.Context, attempt chan struct{}) {
+	err := g.open(ctx)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.ready = err == nil
+	g.err = err
+	g.attempt = nil
+	close(attempt)
+}
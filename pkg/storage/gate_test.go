@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestGateRetriesUntilReady(t *testing.T) {
+	down := errors.New("down")
+	checks := 0
+	gate := NewGate(func(ctx context.Context) error {
+		checks++
+		if checks == 1 {
+			return down
+		}
+
+		return nil
+	})
+
+	err := gate.Wait(context.Background())
+	if err != down {
+		t.Fatalf("expected the failed check, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		err = gate.Wait(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if checks != 2 {
+		t.Errorf("expected a failed check to be retried and a successful one to be kept, got %d checks", checks)
+	}
+}
+
+func TestGateSharesTheCheck(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	checks := 0
+	gate := NewGate(func(ctx context.Context) error {
+		checks++
+		once.Do(func() { close(started) })
+		<-release
+		return nil
+	})
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			errs <- gate.Wait(context.Background())
+		}()
+	}
+
+	// A request, which gives up, does not wait for the running check.
+	<-started
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := gate.Wait(ctx)
+	if err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		err := <-errs
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if checks != 1 {
+		t.Errorf("expected one check, got %d", checks)
+	}
+}
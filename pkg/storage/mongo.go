@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	duplicateKeyCode  = 11000
+	networkErrorLabel = "NetworkError"
+	// The driver does not export a type for server selection errors.
+	serverSelectionErrorPrefix = "server selection error"
+)
+
+// FromMongo translates the errors of the MongoDB driver into the errors of
+// this package. Duplicate key errors are matched to a field through the
+// name of the violated index, as given by indexFields.
+func FromMongo(err error, indexFields map[string]string) error {
+	if err == nil {
+		return nil
+	}
+
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+
+	if isUnavailable(err) {
+		return &UnavailableError{Err: err}
+	}
+
+	var messages []string
+	switch mongoErr := err.(type) {
+	case mongo.WriteException:
+		for _, writeError := range mongoErr.WriteErrors {
+			if writeError.Code == duplicateKeyCode {
+				messages = append(messages, writeError.Message)
+			}
+		}
+	case mongo.CommandError:
+		if mongoErr.Code == duplicateKeyCode {
+			messages = append(messages, mongoErr.Message)
+		}
+	}
+
+	if len(messages) == 0 {
+		return err
+	}
+
+	for _, message := range messages {
+		for index, field := range indexFields {
+			if strings.Contains(message, "index: "+index+" ") {
+				return &DuplicateError{Field: field}
+			}
+		}
+	}
+
+	return &DuplicateError{}
+}
+
+func isUnavailable(err error) bool {
+	if err == mongo.ErrClientDisconnected || strings.HasPrefix(err.Error(), serverSelectionErrorPrefix) {
+		return true
+	}
+
+	commandError, ok := err.(mongo.CommandError)
+	return ok && commandError.HasErrorLabel(networkErrorLabel)
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolationCode = "23505"
+	// The class of the codes of connection errors, like 08006.
+	connectionExceptionClass = "08"
+	cannotConnectNowCode     = "57P03"
+	adminShutdownCode        = "57P01"
+)
+
+// FromPostgres translates the errors of database/sql and lib/pq into the
+// errors of this package. Unique violations are matched to a field through
+// the name of the violated constraint, as given by constraintFields.
+func FromPostgres(err error, constraintFields map[string]string) error {
+	if err == nil {
+		return nil
+	}
+
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if pqErr.Code == uniqueViolationCode {
+			return &DuplicateError{Field: constraintFields[pqErr.Constraint]}
+		}
+
+		if pqErr.Code.Class() == connectionExceptionClass || pqErr.Code == cannotConnectNowCode || pqErr.Code == adminShutdownCode {
+			return &UnavailableError{Err: err}
+		}
+
+		return err
+	}
+
+	var netErr net.Error
+	if err == driver.ErrBadConn || errors.As(err, &netErr) {
+		return &UnavailableError{Err: err}
+	}
+
+	return err
+}
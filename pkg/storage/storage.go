@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"errors"
+)
+
+var (
+	ErrNotFound    = errors.New("Not found")
+	ErrDuplicate   = errors.New("Duplicate")
+	ErrUnavailable = errors.New("Storage unavailable")
+)
+
+// DuplicateError is returned when a write violates a unique constraint.
+// It matches ErrDuplicate with errors.Is.
+type DuplicateError struct {
+	// The name of the field, which must be unique.
+	Field string
+}
+
+func (e *DuplicateError) Error() string {
+	if len(e.Field) == 0 {
+		return ErrDuplicate.Error()
+	}
+
+	return ErrDuplicate.Error() + " " + e.Field
+}
+
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrDuplicate
+}
+
+// UnavailableError is returned when the storage cannot be reached, which is
+// usually temporary. It matches ErrUnavailable with errors.Is.
+type UnavailableError struct {
+	Err error
+}
+
+func (e *UnavailableError) Error() string {
+	return ErrUnavailable.Error() + ": " + e.Err.Error()
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
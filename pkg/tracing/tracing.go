@@ -0,0 +1,151 @@
+// Package tracing creates the OpenTelemetry spans of the application, for
+// the HTTP requests, the handler services and the storage operations.
+//
+// Incoming requests continue the trace of their W3C traceparent header.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"survey-api/pkg/config"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlphttp"
+	"go.opentelemetry.io/otel/exporters/stdout"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	serviceName = "survey-api"
+)
+
+type Service struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	// Nil when the spans are not exported.
+	provider *sdktrace.TracerProvider
+}
+
+// New creates the tracer of the configured exporter. Without an exporter,
+// the spans are not recorded at all.
+func New(c *config.Config) (*Service, error) {
+	switch c.Tracing.Exporter {
+	case config.StdoutTracing:
+		exporter, err := stdout.NewExporter(stdout.WithPrettyPrint(), stdout.WithoutMetricExport())
+		if err != nil {
+			return nil, err
+		}
+
+		return NewWithProcessor(sdktrace.NewSimpleSpanProcessor(exporter)), nil
+	case config.OtlpTracing:
+		exporter, err := newOtlpExporter(c.Tracing.OtlpEndpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewWithProcessor(sdktrace.NewBatchSpanProcessor(exporter)), nil
+	}
+
+	return &Service{
+		tracer:     trace.NewNoopTracerProvider().Tracer(serviceName),
+		propagator: newPropagator(),
+	}, nil
+}
+
+// NewWithProcessor creates a tracer, which records every span that has no
+// unsampled parent and passes it to the processor.
+func NewWithProcessor(processor sdktrace.SpanProcessor) *Service {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.ServiceNameKey.String(serviceName))),
+	)
+	return &Service{
+		tracer:     provider.Tracer(serviceName),
+		propagator: newPropagator(),
+		provider:   provider,
+	}
+}
+
+func newPropagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// The endpoint is validated by the configuration.
+func newOtlpExporter(endpoint string) (*otlp.Exporter, error) {
+	uri, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []otlphttp.Option{otlphttp.WithEndpoint(uri.Host)}
+	if len(uri.Path) > 1 {
+		options = append(options, otlphttp.WithTracesURLPath(uri.Path))
+	}
+
+	if uri.Scheme == "http" {
+		options = append(options, otlphttp.WithInsecure())
+	}
+
+	// The HTTP driver does not connect before the first export.
+	return otlp.NewExporter(context.Background(), otlphttp.NewDriver(options...))
+}
+
+// Start starts a span, which is a child of the span in the context, if any.
+func (s *Service) Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// StartServer starts the span of an incoming request, continuing the trace
+// of its headers.
+func (s *Service) StartServer(r *http.Request, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx := s.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return s.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attributes...))
+}
+
+// Flush exports the ended spans, which are still buffered. Serverless
+// functions flush after every request, since they may be frozen after
+// responding.
+func (s *Service) Flush(ctx context.Context) error {
+	if s.provider == nil {
+		return nil
+	}
+
+	return s.provider.ForceFlush(ctx)
+}
+
+// Shutdown flushes the spans and stops the exporter.
+func (s *Service) Shutdown(ctx context.Context) error {
+	if s.provider == nil {
+		return nil
+	}
+
+	return s.provider.Shutdown(ctx)
+}
+
+// End ends the span, which failed when err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// TraceId returns the id of the trace in the context, or an empty string.
+func TraceId(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return ""
+	}
+
+	return spanContext.TraceID().String()
+}
@@ -1,15 +1,25 @@
 package model
 
 import (
+	"regexp"
+	"strings"
+
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
 )
 
 const (
 	passwordRegex = `^(?=.{8,32}$)(?=.*[A-Z])(?=.*[a-z])(?=.*[0-9]).*`
 )
 
+var (
+	// User names must never be mistaken for an email when logging in.
+	userNameRegex = regexp.MustCompile(`^[^@]+$`)
+)
+
 type RegisterUser struct {
 	FirstName string `json:"first_name"`
 	UserName  string `json:"user_name"`
@@ -19,8 +29,8 @@ type RegisterUser struct {
 }
 
 type LoginUser struct {
-	UserName string `json:"user_name"`
-	Password string `json:"password"`
+	Identifier string `json:"identifier"`
+	Password   string `json:"password"`
 }
 
 type ClientUser struct {
@@ -42,14 +52,24 @@ type User struct {
 func (u *RegisterUser) ToUser() *User {
 	return &User{
 		Id:        primitive.NewObjectID(),
-		FirstName: u.FirstName,
-		UserName:  u.UserName,
-		Email:     u.Email,
+		FirstName: strings.TrimSpace(u.FirstName),
+		UserName:  NormalizeUserName(u.UserName),
+		Email:     NormalizeEmail(u.Email),
 		Password:  u.Password,
 		AvatarUrl: u.AvatarUrl,
 	}
 }
 
+// ToUserFilter resolves the identifier as an email when it contains an
+// "@", which user names cannot, and as a user name otherwise.
+func (u *LoginUser) ToUserFilter() *User {
+	if strings.Contains(u.Identifier, "@") {
+		return &User{Email: NormalizeEmail(u.Identifier)}
+	}
+
+	return &User{UserName: NormalizeUserName(u.Identifier)}
+}
+
 func (u *User) ToClientUser() *ClientUser {
 	return &ClientUser{
 		Id:        u.Id.Hex(),
@@ -62,7 +82,12 @@ func (u *User) ToClientUser() *ClientUser {
 func (u RegisterUser) Validate() error {
 	return validation.ValidateStruct(&u,
 		validation.Field(&u.FirstName, validation.Required, validation.Length(2, 20)),
-		validation.Field(&u.UserName, validation.Required, validation.Length(3, 20)),
+		validation.Field(
+			&u.UserName,
+			validation.Required,
+			validation.Length(3, 20),
+			validation.Match(userNameRegex).Error("must not contain @"),
+		),
 		validation.Field(&u.Email, validation.Required, is.Email),
 		validation.Field(&u.Password, validation.Required),
 	)
@@ -70,7 +95,25 @@ func (u RegisterUser) Validate() error {
 
 func (u LoginUser) Validate() error {
 	return validation.ValidateStruct(&u,
-		validation.Field(&u.UserName, validation.Required),
+		validation.Field(&u.Identifier, validation.Required),
 		validation.Field(&u.Password, validation.Required),
 	)
 }
+
+// NormalizeUserName returns the canonical form under which a user name
+// is stored and looked up, so that visually identical names collide.
+func NormalizeUserName(userName string) string {
+	return normalize(userName)
+}
+
+// NormalizeEmail returns the canonical form under which an email is
+// stored and looked up.
+func NormalizeEmail(email string) string {
+	return normalize(email)
+}
+
+func normalize(value string) string {
+	value = norm.NFKC.String(strings.TrimSpace(value))
+	value = norm.NFKC.String(cases.Fold().String(value))
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,28 @@
+package repo_test
+
+import (
+	"survey-api/pkg/config"
+	"survey-api/pkg/mongodb/mongodbtest"
+	"survey-api/pkg/postgres/postgrestest"
+	"survey-api/pkg/user/repo"
+	"survey-api/pkg/user/repo/repotest"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.Repository {
+		return repo.NewMemory()
+	})
+}
+
+func TestMongodb(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.Repository {
+		return repo.New(mongodbtest.Database(t), config.Default())
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.Repository {
+		return repo.NewPostgres(postgrestest.DB(t), config.Default())
+	})
+}
@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"context"
+	"survey-api/pkg/metrics"
+	"survey-api/pkg/tracing"
+	"survey-api/pkg/user/model"
+	"time"
+
+	"go.opentelemetry.io/otel/semconv"
+)
+
+// Instrumented measures the duration of every operation of a Repository
+// and traces it.
+type Instrumented struct {
+	next    Repository
+	observe func(method string, start time.Time, err error)
+	tracing *tracing.Service
+	storage string
+}
+
+func NewInstrumented(next Repository, metrics *metrics.Service, tracingService *tracing.Service, storage string) *Instrumented {
+	return &Instrumented{
+		next:    next,
+		observe: metrics.StorageObserver(storage, "user"),
+		tracing: tracingService,
+		storage: storage,
+	}
+}
+
+// The returned function ends the operation.
+func (i *Instrumented) begin(ctx context.Context, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := i.tracing.Start(ctx, "user."+method,
+		semconv.DBSystemKey.String(i.storage),
+		semconv.DBOperationKey.String(method),
+	)
+	return ctx, func(err error) {
+		tracing.End(span, err)
+		i.observe(method, start, err)
+	}
+}
+
+func (i *Instrumented) InsertOne(ctx context.Context, user *model.User) (*model.User, error) {
+	ctx, end := i.begin(ctx, "InsertOne")
+	user, err := i.next.InsertOne(ctx, user)
+	end(err)
+	return user, err
+}
+
+func (i *Instrumented) FindById(ctx context.Context, userId string) (*model.User, error) {
+	ctx, end := i.begin(ctx, "FindById")
+	user, err := i.next.FindById(ctx, userId)
+	end(err)
+	return user, err
+}
+
+func (i *Instrumented) FindOne(ctx context.Context, userFilter *model.User) (*model.User, error) {
+	ctx, end := i.begin(ctx, "FindOne")
+	user, err := i.next.FindOne(ctx, userFilter)
+	end(err)
+	return user, err
+}
+
+func (i *Instrumented) UpdateOne(ctx context.Context, user *model.User) (*model.User, error) {
+	ctx, end := i.begin(ctx, "UpdateOne")
+	user, err := i.next.UpdateOne(ctx, user)
+	end(err)
+	return user, err
+}
@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"context"
+	"survey-api/pkg/storage"
+	"survey-api/pkg/user/model"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Memory is an in-memory implementation of Repository for tests and local
+// demos. It is safe for concurrent use.
+type Memory struct {
+	mutex sync.RWMutex
+	users map[primitive.ObjectID]model.User
+}
+
+func NewMemory() *Memory {
+	return &Memory{users: make(map[primitive.ObjectID]model.User)}
+}
+
+func (m *Memory) InsertOne(ctx context.Context, u *model.User) (*model.User, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if u.Id.IsZero() {
+		u.Id = primitive.NewObjectID()
+	}
+
+	_, ok := m.users[u.Id]
+	if ok {
+		return nil, &storage.DuplicateError{}
+	}
+
+	err = m.checkUnique(u)
+	if err != nil {
+		return nil, err
+	}
+
+	m.users[u.Id] = *u
+	return u, nil
+}
+
+func (m *Memory) FindById(ctx context.Context, userIdString string) (*model.User, error) {
+	userId, err := primitive.ObjectIDFromHex(userIdString)
+	if err != nil || userId.IsZero() {
+		return nil, storage.ErrNotFound
+	}
+
+	return m.FindOne(ctx, &model.User{Id: userId})
+}
+
+func (m *Memory) FindOne(ctx context.Context, userFilter *model.User) (*model.User, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for _, user := range m.users {
+		if matchesUser(userFilter, &user) {
+			return &user, nil
+		}
+	}
+
+	return nil, storage.ErrNotFound
+}
+
+func (m *Memory) UpdateOne(ctx context.Context, user *model.User) (*model.User, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	updated, ok := m.users[user.Id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	setString(&updated.FirstName, user.FirstName)
+	setString(&updated.UserName, user.UserName)
+	setString(&updated.Email, user.Email)
+	setString(&updated.Password, user.Password)
+	setString(&updated.AvatarUrl, user.AvatarUrl)
+	err = m.checkUnique(&updated)
+	if err != nil {
+		return nil, err
+	}
+
+	m.users[user.Id] = updated
+	return user, nil
+}
+
+// Mirrors the case-insensitive unique indexes of the MongoDB collection.
+func (m *Memory) checkUnique(user *model.User) error {
+	for id, other := range m.users {
+		if id == user.Id {
+			continue
+		}
+
+		if model.NormalizeUserName(other.UserName) == model.NormalizeUserName(user.UserName) {
+			return &storage.DuplicateError{Field: "user_name"}
+		}
+
+		if model.NormalizeEmail(other.Email) == model.NormalizeEmail(user.Email) {
+			return &storage.DuplicateError{Field: "email"}
+		}
+	}
+
+	return nil
+}
+
+func matchesUser(filter *model.User, user *model.User) bool {
+	return (filter.Id.IsZero() || filter.Id == user.Id) &&
+		(len(filter.FirstName) == 0 || filter.FirstName == user.FirstName) &&
+		(len(filter.UserName) == 0 || model.NormalizeUserName(filter.UserName) == model.NormalizeUserName(user.UserName)) &&
+		(len(filter.Email) == 0 || model.NormalizeEmail(filter.Email) == model.NormalizeEmail(user.Email)) &&
+		(len(filter.Password) == 0 || filter.Password == user.Password) &&
+		(len(filter.AvatarUrl) == 0 || filter.AvatarUrl == user.AvatarUrl)
+}
+
+func setString(field *string, value string) {
+	if len(value) != 0 {
+		*field = value
+	}
+}
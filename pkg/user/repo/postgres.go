@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"survey-api/pkg/config"
+	"survey-api/pkg/postgres"
+	"survey-api/pkg/storage"
+	"survey-api/pkg/user/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	userColumns = "id, first_name, user_name, email, password, avatar_url"
+)
+
+var (
+	// The unique indexes on lower(user_name) and lower(email), created by
+	// the migrations in pkg/migration.
+	constraintFields = map[string]string{
+		"users_user_name_key": "user_name",
+		"users_email_key":     "email",
+	}
+)
+
+// Postgres is the PostgreSQL implementation of Repository.
+type Postgres struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewPostgres(db *sql.DB, config *config.Config) *Postgres {
+	return &Postgres{db: db, timeout: config.Postgres.OperationTimeout}
+}
+
+func (p *Postgres) InsertOne(ctx context.Context, u *model.User) (*model.User, error) {
+	if u.Id.IsZero() {
+		u.Id = primitive.NewObjectID()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	_, err := p.db.ExecContext(ctx, "INSERT INTO users ("+userColumns+") VALUES ($1, $2, $3, $4, $5, $6)",
+		u.Id.Hex(), u.FirstName, u.UserName, u.Email, u.Password, u.AvatarUrl)
+	if err != nil {
+		return nil, storage.FromPostgres(err, constraintFields)
+	}
+
+	return u, nil
+}
+
+func (p *Postgres) FindById(ctx context.Context, userIdString string) (*model.User, error) {
+	userId, err := primitive.ObjectIDFromHex(userIdString)
+	if err != nil || userId.IsZero() {
+		return nil, storage.ErrNotFound
+	}
+
+	return p.FindOne(ctx, &model.User{Id: userId})
+}
+
+func (p *Postgres) FindOne(ctx context.Context, userFilter *model.User) (*model.User, error) {
+	var params postgres.Params
+	var conditions []string
+	if !userFilter.Id.IsZero() {
+		conditions = append(conditions, "id = "+params.Add(userFilter.Id.Hex()))
+	}
+
+	if len(userFilter.FirstName) != 0 {
+		conditions = append(conditions, "first_name = "+params.Add(userFilter.FirstName))
+	}
+
+	// Compared like the unique indexes, which then serve the lookup.
+	if len(userFilter.UserName) != 0 {
+		conditions = append(conditions, "lower(user_name) = lower("+params.Add(userFilter.UserName)+")")
+	}
+
+	if len(userFilter.Email) != 0 {
+		conditions = append(conditions, "lower(email) = lower("+params.Add(userFilter.Email)+")")
+	}
+
+	if len(userFilter.Password) != 0 {
+		conditions = append(conditions, "password = "+params.Add(userFilter.Password))
+	}
+
+	if len(userFilter.AvatarUrl) != 0 {
+		conditions = append(conditions, "avatar_url = "+params.Add(userFilter.AvatarUrl))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	row := p.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users"+postgres.Where(conditions)+" LIMIT 1", params.Args()...)
+	var user model.User
+	var id string
+	err := row.Scan(&id, &user.FirstName, &user.UserName, &user.Email, &user.Password, &user.AvatarUrl)
+	if err != nil {
+		return nil, storage.FromPostgres(err, constraintFields)
+	}
+
+	user.Id, err = postgres.ObjectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (p *Postgres) UpdateOne(ctx context.Context, user *model.User) (*model.User, error) {
+	var params postgres.Params
+	var sets []string
+	columns := []struct {
+		name  string
+		value string
+	}{
+		{"first_name", user.FirstName},
+		{"user_name", user.UserName},
+		{"email", user.Email},
+		{"password", user.Password},
+		{"avatar_url", user.AvatarUrl},
+	}
+	for _, column := range columns {
+		if len(column.value) != 0 {
+			sets = append(sets, column.name+" = "+params.Add(column.value))
+		}
+	}
+
+	// Like an empty $set, an update without fields only checks that the
+	// user exists.
+	if len(sets) == 0 {
+		sets = append(sets, "id = id")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	statement := "UPDATE users SET " + strings.Join(sets, ", ") + " WHERE id = " + params.Add(user.Id.Hex())
+	result, err := p.db.ExecContext(ctx, statement, params.Args()...)
+	if err != nil {
+		return nil, storage.FromPostgres(err, constraintFields)
+	}
+
+	err = postgres.ExpectRows(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -2,6 +2,8 @@ package repo
 
 import (
 	"context"
+	"survey-api/pkg/config"
+	"survey-api/pkg/storage"
 	"survey-api/pkg/user/model"
 	"time"
 
@@ -11,47 +13,76 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type Service struct {
-	client *mongo.Client
-}
+const (
+	// The indexes are created by the migrations in pkg/migration.
+	userNameIndex = "user_name_ci"
+	emailIndex    = "email_ci"
+)
 
-func New(client *mongo.Client) (*Service, error) {
-	repo := &Service{client: client}
-	err := repo.createUserIndexes()
-	if err != nil {
-		return nil, err
+var (
+	indexFields = map[string]string{
+		userNameIndex: "user_name",
+		emailIndex:    "email",
 	}
 
-	return repo, nil
+	// Case-insensitive collation, used by the unique indexes and by every
+	// lookup, so that queries can be served from those indexes.
+	userCollation = &options.Collation{Locale: "en", Strength: 2}
+)
+
+// Repository stores the users. Missing users are reported with
+// storage.ErrNotFound, taken user names and emails with a
+// storage.DuplicateError.
+type Repository interface {
+	InsertOne(ctx context.Context, user *model.User) (*model.User, error)
+	FindById(ctx context.Context, userId string) (*model.User, error)
+	// FindOne matches the non-zero fields of the filter. User names and
+	// emails are compared case-insensitively.
+	FindOne(ctx context.Context, userFilter *model.User) (*model.User, error)
+	// UpdateOne sets the non-zero fields of the user.
+	UpdateOne(ctx context.Context, user *model.User) (*model.User, error)
 }
 
-func (s *Service) InsertOne(u *model.User) (*model.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+// Service is the MongoDB implementation of Repository.
+type Service struct {
+	db      *mongo.Database
+	timeout time.Duration
+}
+
+func New(db *mongo.Database, config *config.Config) *Service {
+	return &Service{db: db, timeout: config.Mongodb.OperationTimeout}
+}
+
+func (s *Service) InsertOne(ctx context.Context, u *model.User) (*model.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	_, err := s.userCollection().InsertOne(ctx, u)
 	defer cancel()
 	if err != nil {
-		return nil, err
+		return nil, storage.FromMongo(err, indexFields)
 	}
 
 	return u, nil
 }
 
-func (s *Service) FindById(userIdString string) (*model.User, error) {
+func (s *Service) FindById(ctx context.Context, userIdString string) (*model.User, error) {
 	userId, err := primitive.ObjectIDFromHex(userIdString)
-	if err != nil {
-		return nil, err
+	// A zero id is left out of the filter, which would then match any
+	// document.
+	if err != nil || userId.IsZero() {
+		return nil, storage.ErrNotFound
 	}
 
-	return s.FindOne(&model.User{Id: userId})
+	return s.FindOne(ctx, &model.User{Id: userId})
 }
 
-func (s *Service) FindOne(userFilter *model.User) (*model.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	result := s.userCollection().FindOne(ctx, userFilter)
+func (s *Service) FindOne(ctx context.Context, userFilter *model.User) (*model.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	findOptions := options.FindOne().SetCollation(userCollation)
+	result := s.userCollection().FindOne(ctx, userFilter, findOptions)
 	defer cancel()
 	err := result.Err()
 	if err != nil {
-		return nil, err
+		return nil, storage.FromMongo(err, indexFields)
 	}
 
 	var user *model.User
@@ -63,28 +94,20 @@ func (s *Service) FindOne(userFilter *model.User) (*model.User, error) {
 	return user, nil
 }
 
-func (s *Service) userCollection() *mongo.Collection {
-	return s.client.Database("survey").Collection("user")
-}
-
-func (s *Service) createUserIndexes() error {
-	collection := s.userCollection()
-	indexes := []mongo.IndexModel{
-		{
-			Keys:    bson.M{"user_name": "text"},
-			Options: options.Index().SetUnique(true),
-		}, {
-			Keys:    bson.M{"email": 1},
-			Options: options.Index().SetUnique(true),
-		},
-	}
+func (s *Service) UpdateOne(ctx context.Context, user *model.User) (*model.User, error) {
+	userFilter := &model.User{Id: user.Id}
 
-	context, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	_, err := collection.Indexes().CreateMany(context, indexes)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
+	result := s.userCollection().FindOneAndUpdate(ctx, userFilter, bson.M{"$set": user})
+	err := result.Err()
 	if err != nil {
-		return err
+		return nil, storage.FromMongo(err, indexFields)
 	}
 
-	return nil
+	return user, nil
+}
+
+func (s *Service) userCollection() *mongo.Collection {
+	return s.db.Collection("user")
 }
@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+	"survey-api/pkg/mongodb/mongodbtest"
+	"survey-api/pkg/user/model"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOperationsFollowContext(t *testing.T) {
+	timeout := 50 * time.Millisecond
+	s := &Service{db: mongodbtest.Unreachable(t), timeout: timeout}
+	user := &model.User{Id: primitive.NewObjectID(), UserName: "user", Email: "user@example.com"}
+	mongodbtest.TestCancellation(t, timeout, map[string]mongodbtest.Operation{
+		"InsertOne": func(ctx context.Context) error {
+			_, err := s.InsertOne(ctx, user)
+			return err
+		},
+		"FindById": func(ctx context.Context) error {
+			_, err := s.FindById(ctx, user.Id.Hex())
+			return err
+		},
+		"FindOne": func(ctx context.Context) error {
+			_, err := s.FindOne(ctx, &model.User{UserName: user.UserName})
+			return err
+		},
+		"UpdateOne": func(ctx context.Context) error {
+			_, err := s.UpdateOne(ctx, user)
+			return err
+		},
+	})
+}
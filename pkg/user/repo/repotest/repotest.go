@@ -0,0 +1,217 @@
+// Package repotest is the contract every implementation of the user
+// repository has to satisfy.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"survey-api/pkg/storage"
+	"survey-api/pkg/user/model"
+	"survey-api/pkg/user/repo"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Run runs the contract against a new, empty repository for every test.
+func Run(t *testing.T, newRepository func(t *testing.T) repo.Repository) {
+	tests := map[string]func(t *testing.T, r repo.Repository){
+		"InsertAndFind":         testInsertAndFind,
+		"FindIsCaseInsensitive": testFindIsCaseInsensitive,
+		"NotFound":              testNotFound,
+		"DuplicateUserName":     testDuplicateUserName,
+		"DuplicateEmail":        testDuplicateEmail,
+		"UpdateSetsFields":      testUpdateSetsFields,
+		"UpdateNotFound":        testUpdateNotFound,
+		"ConcurrentInserts":     testConcurrentInserts,
+		"CancelledContext":      testCancelledContext,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepository(t))
+		})
+	}
+}
+
+func newUser(userName string) *model.User {
+	return &model.User{
+		Id:        primitive.NewObjectID(),
+		FirstName: "First",
+		UserName:  userName,
+		Email:     userName + "@example.com",
+		Password:  "hash",
+	}
+}
+
+func insert(t *testing.T, r repo.Repository, user *model.User) {
+	t.Helper()
+	_, err := r.InsertOne(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testInsertAndFind(t *testing.T, r repo.Repository) {
+	user := newUser("alice")
+	insert(t, r, user)
+
+	found, err := r.FindById(context.Background(), user.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *found != *user {
+		t.Fatalf("expected %+v, got %+v", user, found)
+	}
+
+	found.FirstName = "Changed"
+	found, err = r.FindOne(context.Background(), &model.User{UserName: user.UserName})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.FirstName != user.FirstName {
+		t.Fatal("expected the stored user not to change with a returned one")
+	}
+}
+
+func testFindIsCaseInsensitive(t *testing.T, r repo.Repository) {
+	user := newUser("alice")
+	insert(t, r, user)
+
+	filters := []*model.User{{UserName: "ALICE"}, {Email: "Alice@Example.com"}}
+	for _, filter := range filters {
+		found, err := r.FindOne(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("%+v: %v", filter, err)
+		}
+
+		if found.Id != user.Id {
+			t.Fatalf("%+v: expected %v, got %v", filter, user.Id, found.Id)
+		}
+	}
+}
+
+func testNotFound(t *testing.T, r repo.Repository) {
+	insert(t, r, newUser("alice"))
+
+	_, err := r.FindById(context.Background(), primitive.NewObjectID().Hex())
+	if err != storage.ErrNotFound {
+		t.Fatalf("unknown id: expected %v, got %v", storage.ErrNotFound, err)
+	}
+
+	_, err = r.FindById(context.Background(), "invalid")
+	if err != storage.ErrNotFound {
+		t.Fatalf("invalid id: expected %v, got %v", storage.ErrNotFound, err)
+	}
+
+	_, err = r.FindById(context.Background(), primitive.NilObjectID.Hex())
+	if err != storage.ErrNotFound {
+		t.Fatalf("zero id: expected %v, got %v", storage.ErrNotFound, err)
+	}
+
+	_, err = r.FindOne(context.Background(), &model.User{UserName: "bob"})
+	if err != storage.ErrNotFound {
+		t.Fatalf("unknown user name: expected %v, got %v", storage.ErrNotFound, err)
+	}
+}
+
+func testDuplicateUserName(t *testing.T, r repo.Repository) {
+	insert(t, r, newUser("alice"))
+
+	duplicate := newUser("Alice")
+	duplicate.Email = "other@example.com"
+	_, err := r.InsertOne(context.Background(), duplicate)
+	expectDuplicate(t, err, "user_name")
+}
+
+func testDuplicateEmail(t *testing.T, r repo.Repository) {
+	insert(t, r, newUser("alice"))
+
+	duplicate := newUser("bob")
+	duplicate.Email = "ALICE@example.com"
+	_, err := r.InsertOne(context.Background(), duplicate)
+	expectDuplicate(t, err, "email")
+}
+
+func testUpdateSetsFields(t *testing.T, r repo.Repository) {
+	user := newUser("alice")
+	insert(t, r, user)
+
+	_, err := r.UpdateOne(context.Background(), &model.User{Id: user.Id, Password: "new hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := r.FindById(context.Background(), user.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.Password != "new hash" || found.UserName != user.UserName || found.Email != user.Email {
+		t.Fatalf("expected only the password to change, got %+v", found)
+	}
+
+	other := newUser("bob")
+	insert(t, r, other)
+	_, err = r.UpdateOne(context.Background(), &model.User{Id: other.Id, UserName: "ALICE"})
+	expectDuplicate(t, err, "user_name")
+}
+
+func testUpdateNotFound(t *testing.T, r repo.Repository) {
+	_, err := r.UpdateOne(context.Background(), &model.User{Id: primitive.NewObjectID(), Password: "hash"})
+	if err != storage.ErrNotFound {
+		t.Fatalf("expected %v, got %v", storage.ErrNotFound, err)
+	}
+}
+
+func testConcurrentInserts(t *testing.T, r repo.Repository) {
+	const inserts = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, inserts)
+	for i := 0; i < inserts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.InsertOne(context.Background(), newUser("alice"))
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	inserted := 0
+	for err := range errs {
+		if err == nil {
+			inserted++
+			continue
+		}
+
+		if !errors.Is(err, storage.ErrDuplicate) {
+			t.Fatal(err)
+		}
+	}
+
+	if inserted != 1 {
+		t.Fatalf("expected exactly 1 insert to succeed, got %d", inserted)
+	}
+}
+
+func testCancelledContext(t *testing.T, r repo.Repository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := r.InsertOne(ctx, newUser("alice"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func expectDuplicate(t *testing.T, err error, field string) {
+	t.Helper()
+	var duplicateError *storage.DuplicateError
+	if !errors.As(err, &duplicateError) || duplicateError.Field != field {
+		t.Fatalf("expected a duplicate %s, got %v", field, err)
+	}
+}